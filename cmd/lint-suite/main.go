@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/spf13/pflag"
+	"github.com/stringintech/kernel-bindings-tests/runner"
+	"github.com/stringintech/kernel-bindings-tests/testdata"
+)
+
+func main() {
+	maxDisabled := pflag.Int("max-disabled-per-suite", 3, "Warn when a suite has more than this many disabled tests")
+	handlerVersionsPath := pflag.String("handler-versions", defaultHandlerVersionsPath, "JSON manifest of supported handler version ranges to check suite version ranges against")
+	pflag.Parse()
+
+	// A missing manifest at the default location disables the check
+	var supported []versionRange
+	if _, err := os.Stat(*handlerVersionsPath); err == nil || pflag.CommandLine.Changed("handler-versions") {
+		supported, err = loadHandlerVersions(*handlerVersionsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading handler versions: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	testFiles, err := fs.Glob(testdata.FS, "*.json")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding test files: %v\n", err)
+		os.Exit(1)
+	}
+	sort.Strings(testFiles)
+
+	warnings := 0
+	var suites []runner.TestSuite
+	for _, testFile := range testFiles {
+		suite, err := runner.LoadTestSuiteFromFS(testdata.FS, testFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading test suite %s: %v\n", testFile, err)
+			os.Exit(1)
+		}
+		suites = append(suites, *suite)
+
+		for _, warning := range lintSuite(suite, *maxDisabled, supported) {
+			fmt.Printf("%s: warning: %s\n", testFile, warning)
+			warnings++
+		}
+	}
+
+	if err := runner.ValidateSuiteIDs(suites); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: duplicate test IDs:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	if warnings > 0 {
+		fmt.Printf("\n%d warning(s)\n", warnings)
+		os.Exit(1)
+	}
+	fmt.Printf("%d test suite(s) OK\n", len(testFiles))
+}
+
+// lintSuite returns warnings for a single test suite. Suites are checked against the
+// supported handler version ranges unless none are given.
+func lintSuite(suite *runner.TestSuite, maxDisabled int, supported []versionRange) []string {
+	var warnings []string
+
+	var disabled []string
+	for _, test := range suite.Tests {
+		if test.Disabled {
+			disabled = append(disabled, test.Request.ID)
+		}
+	}
+	if len(disabled) > maxDisabled {
+		warnings = append(warnings, fmt.Sprintf("%d disabled tests (max %d): %v", len(disabled), maxDisabled, disabled))
+	}
+
+	var focused []string
+	for _, test := range suite.Tests {
+		if test.Only {
+			focused = append(focused, test.Request.ID)
+		}
+	}
+	if len(focused) > 0 {
+		warnings = append(warnings, fmt.Sprintf(`tests marked "only" hide the rest of the suite: %v`, focused))
+	}
+
+	// Errors resolving refs are reported when running the suite
+	if orphaned, err := suite.OrphanedRefs(); err == nil && len(orphaned) > 0 {
+		warnings = append(warnings, fmt.Sprintf("refs created but never used: %v", orphaned))
+	}
+
+	if len(supported) > 0 && !overlapsAny(suite, supported) {
+		warnings = append(warnings, fmt.Sprintf("handler version range [%s, %s] does not overlap any supported handler version range",
+			orUnbounded(suite.MinimumHandlerVersion), orUnbounded(suite.MaximumHandlerVersion)))
+	}
+
+	return warnings
+}
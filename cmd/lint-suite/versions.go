@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// defaultHandlerVersionsPath is the handler version manifest checked by default,
+// relative to the repository root
+const defaultHandlerVersionsPath = "handler_versions.json"
+
+// versionRange is an entry of the handler version manifest: an inclusive range of
+// handler versions that is currently supported. Empty bounds are unbounded.
+type versionRange struct {
+	Label   string `json:"label"`
+	Minimum string `json:"minimum,omitempty"`
+	Maximum string `json:"maximum,omitempty"`
+}
+
+// loadHandlerVersions reads and validates the handler version manifest
+func loadHandlerVersions(path string) ([]versionRange, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ranges []versionRange
+	if err := json.Unmarshal(data, &ranges); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for _, r := range ranges {
+		if err := runner.ValidateVersionRange(r.Minimum, r.Maximum); err != nil {
+			return nil, fmt.Errorf("%s: range %q: %w", path, r.Label, err)
+		}
+	}
+	return ranges, nil
+}
+
+// overlapsAny reports whether the suite's handler version range overlaps any of the
+// supported ranges. Bounds were validated when loading, so errors are not expected here.
+func overlapsAny(suite *runner.TestSuite, supported []versionRange) bool {
+	for _, r := range supported {
+		ok, _ := runner.VersionRangesOverlap(suite.MinimumHandlerVersion, suite.MaximumHandlerVersion, r.Minimum, r.Maximum)
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// orUnbounded returns a version bound for display
+func orUnbounded(bound string) string {
+	if bound == "" {
+		return "*"
+	}
+	return bound
+}
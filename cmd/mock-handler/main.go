@@ -70,14 +70,84 @@ func buildTestIndex() (map[string]string, error) {
 	return index, nil
 }
 
+// invalidRequestResponse is returned for requests that cannot be processed at all
+var invalidRequestResponse = runner.Response{
+	Error: &runner.Error{
+		Code: &runner.ErrorCode{
+			Type:   "Handler",
+			Member: "INVALID_REQUEST",
+		},
+	},
+}
+
+// validateRequest checks the fields every request must have
+func validateRequest(req runner.Request) error {
+	if req.ID == "" {
+		return fmt.Errorf("request has no id")
+	}
+	if req.Method == "" {
+		return fmt.Errorf("request %s has no method", req.ID)
+	}
+	if len(req.Params) > 0 && !json.Valid(req.Params) {
+		return fmt.Errorf("request %s has invalid params", req.ID)
+	}
+	return nil
+}
+
 // handleRequest processes a single request and outputs the expected response
 func handleRequest(line string, testIndex map[string]string) error {
-	// Parse request
+	// Parse request. Malformed requests still get an error response so the runner
+	// is not left waiting.
 	var req runner.Request
 	if err := json.Unmarshal([]byte(line), &req); err != nil {
+		if writeErr := writeResponse("", invalidRequestResponse); writeErr != nil {
+			return writeErr
+		}
 		return fmt.Errorf("failed to parse request: %w", err)
 	}
 
+	// Reject requests missing required fields before dispatching on the method, so every
+	// method reports them the same way
+	if err := validateRequest(req); err != nil {
+		if writeErr := writeResponse(req.ID, invalidRequestResponse); writeErr != nil {
+			return writeErr
+		}
+		return err
+	}
+
+	// Protocol-level liveness check
+	if req.Method == "__ping__" {
+		return writeResponse(req.ID, runner.Response{Result: runner.Result("true")})
+	}
+
+	// Protocol-level version query, used by suites with required_handler_version
+	if req.Method == "__version__" {
+		result, err := json.Marshal(handlerVersion())
+		if err != nil {
+			return err
+		}
+		return writeResponse(req.ID, runner.Response{Result: result})
+	}
+
+	// Protocol-level environment query, used by suites with assert_handler_env
+	if req.Method == "__get_env__" {
+		var params struct {
+			Keys []string `json:"keys"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return writeResponse(req.ID, invalidRequestResponse)
+		}
+		env := make(map[string]string, len(params.Keys))
+		for _, key := range params.Keys {
+			env[key] = os.Getenv(key)
+		}
+		result, err := json.Marshal(env)
+		if err != nil {
+			return err
+		}
+		return writeResponse(req.ID, runner.Response{Result: result})
+	}
+
 	filename, ok := testIndex[req.ID]
 	if !ok {
 		resp := runner.Response{
@@ -88,7 +158,7 @@ func handleRequest(line string, testIndex map[string]string) error {
 				},
 			},
 		}
-		return writeResponse(resp)
+		return writeResponse(req.ID, resp)
 	}
 
 	// Load the test suite containing this test case
@@ -102,7 +172,7 @@ func handleRequest(line string, testIndex map[string]string) error {
 				},
 			},
 		}
-		return writeResponse(resp)
+		return writeResponse(req.ID, resp)
 	}
 
 	// Find the specific test case
@@ -122,7 +192,7 @@ func handleRequest(line string, testIndex map[string]string) error {
 				},
 			},
 		}
-		return writeResponse(resp)
+		return writeResponse(req.ID, resp)
 	}
 
 	// Verify method matches
@@ -135,19 +205,22 @@ func handleRequest(line string, testIndex map[string]string) error {
 				},
 			},
 		}
-		return writeResponse(resp)
+		return writeResponse(req.ID, resp)
 	}
 
 	// Build response based on expected result
-	return writeResponse(runner.Response{
+	return writeResponse(req.ID, runner.Response{
 		Result: testCase.ExpectedResponse.Result,
 		Error:  testCase.ExpectedResponse.Error,
 	})
 }
 
-// writeResponse writes a response to stdout as JSON
-func writeResponse(resp runner.Response) error {
-	data, err := json.Marshal(resp)
+// writeResponse writes a response to stdout as JSON, echoing the request ID
+func writeResponse(id string, resp runner.Response) error {
+	data, err := json.Marshal(struct {
+		ID string `json:"id,omitempty"`
+		runner.Response
+	}{id, resp})
 	if err != nil {
 		return fmt.Errorf("failed to marshal response: %w", err)
 	}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"runtime/debug"
+
+	"golang.org/x/mod/semver"
+)
+
+// devVersion is reported by __version__ for binaries not built from a tagged module
+// version (e.g., with go build in a checkout)
+const devVersion = "0.0.0-dev"
+
+// versionResult is the result of the __version__ protocol method
+type versionResult struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+}
+
+// handlerVersion returns the module version and VCS revision embedded in the binary
+func handlerVersion() versionResult {
+	result := versionResult{Version: devVersion}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return result
+	}
+	if semver.IsValid(info.Main.Version) {
+		result.Version = info.Main.Version[1:]
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			result.Commit = setting.Value
+		}
+	}
+	return result
+}
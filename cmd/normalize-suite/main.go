@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/pflag"
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// defaultIDPattern matches IDs that carry no information of their own: serial IDs such as
+// "test-00042" or "chain#7" and IDs previously produced by runner.GenerateTestID
+// (optionally with a collision suffix). Only these are regenerated unless
+// --regenerate-all is set.
+var defaultIDPattern = regexp.MustCompile(`^(test[-_#]?\d+|[A-Za-z]+#\d+|[A-Za-z0-9_.]+_[0-9a-f]{8}(_\d+)?)$`)
+
+func main() {
+	dir := pflag.String("dir", "./testdata", "Directory containing test suite JSON files")
+	regenerateAll := pflag.Bool("regenerate-all", false, "Regenerate all test IDs, including hand-crafted ones")
+	pflag.Parse()
+
+	files, err := filepath.Glob(filepath.Join(*dir, "*.json"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding test files: %v\n", err)
+		os.Exit(1)
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "No test files found in %s\n", *dir)
+		os.Exit(1)
+	}
+	sort.Strings(files)
+
+	suites := make([]*suiteFile, len(files))
+	for i, file := range files {
+		suites[i], err = loadSuiteFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", file, err)
+			os.Exit(1)
+		}
+	}
+
+	// Test IDs must be unique across suites, so generated IDs must not collide with IDs
+	// kept in any file
+	used := make(map[string]bool)
+	for _, suite := range suites {
+		for _, test := range suite.Tests {
+			if !suite.regenerates(test.Request.ID, *regenerateAll) {
+				used[test.Request.ID] = true
+			}
+		}
+	}
+
+	for _, suite := range suites {
+		if suite.IDPattern != "" {
+			fmt.Printf("%s: skipped, as generated IDs would not match its id_pattern\n", suite.path)
+			continue
+		}
+		changed, err := suite.normalize(*regenerateAll, used)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error normalizing %s: %v\n", suite.path, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s: %d ID(s) regenerated\n", suite.path, changed)
+	}
+}
+
+// suiteFile is a test suite together with the file it was loaded from and the location
+// of its test IDs in that file
+type suiteFile struct {
+	runner.TestSuite
+	path  string
+	data  []byte
+	spans []testSpans // Parallel to Tests
+}
+
+// loadSuiteFile reads and parses a suite file
+func loadSuiteFile(path string) (*suiteFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &suiteFile{path: path, data: data}
+	if err := json.Unmarshal(data, &f.TestSuite); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if f.spans, err = locateTests(data); err != nil {
+		return nil, fmt.Errorf("failed to locate test IDs: %w", err)
+	}
+	if len(f.spans) != len(f.Tests) {
+		return nil, fmt.Errorf("found %d tests, expected %d", len(f.spans), len(f.Tests))
+	}
+	for i, spans := range f.spans {
+		if spans.id.end == 0 {
+			return nil, fmt.Errorf("test %d has no request id", i)
+		}
+	}
+	return f, nil
+}
+
+// regenerates reports whether the test ID is replaced when normalizing the suite
+func (f *suiteFile) regenerates(id string, regenerateAll bool) bool {
+	return f.IDPattern == "" && (regenerateAll || defaultIDPattern.MatchString(id))
+}
+
+// normalize regenerates test IDs in the suite file, updating the depends_on lists that
+// refer to them, and writes it back in place. IDs are replaced textually so the rest of
+// the file keeps its original formatting. Generated IDs are added to used. Returns the
+// number of IDs that changed.
+func (f *suiteFile) normalize(regenerateAll bool, used map[string]bool) (int, error) {
+	renamed := make(map[string]string)
+	var edits []idEdit
+	for i, test := range f.Tests {
+		oldID := test.Request.ID
+		if !f.regenerates(oldID, regenerateAll) {
+			continue
+		}
+
+		generated := runner.GenerateTestID(test.Request.Method, test.Request.Params)
+		newID := generated
+		for n := 2; used[newID]; n++ {
+			newID = generated + "_" + strconv.Itoa(n)
+		}
+		used[newID] = true
+
+		if newID != oldID {
+			renamed[oldID] = newID
+			edits = append(edits, idEdit{span: f.spans[i].id.span, newID: newID})
+		}
+	}
+	if len(edits) == 0 {
+		return 0, nil
+	}
+
+	// Tests refer to each other by ID in depends_on, which must follow the renames
+	for _, spans := range f.spans {
+		for _, dep := range spans.dependsOn {
+			if newID, ok := renamed[dep.value]; ok {
+				edits = append(edits, idEdit{span: dep.span, newID: newID})
+			}
+		}
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var out bytes.Buffer
+	prev := 0
+	for _, edit := range edits {
+		newJSON, _ := json.Marshal(edit.newID)
+		out.Write(f.data[prev:edit.start])
+		out.Write(newJSON)
+		prev = edit.end
+	}
+	out.Write(f.data[prev:])
+
+	return len(renamed), os.WriteFile(f.path, out.Bytes(), 0o644)
+}
+
+// idEdit describes the replacement of a JSON string holding a test ID in a file
+type idEdit struct {
+	span
+	newID string
+}
+
+// span is the location [start, end) of a JSON value in a file
+type span struct {
+	start int
+	end   int
+}
+
+// stringValue is a JSON string value and its location
+type stringValue struct {
+	span
+	value string
+}
+
+// testSpans holds the locations of a test's request ID and of the entries of its
+// depends_on list
+type testSpans struct {
+	id        stringValue
+	dependsOn []stringValue
+}
+
+// locateTests returns the locations of the IDs of every test in a suite file, by walking
+// its JSON tokens, so that only the "id" of a test's "request" is matched
+func locateTests(data []byte) ([]testSpans, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var tests []testSpans
+	err := walkObject(dec, func(key string) error {
+		if key != "tests" {
+			return skipValue(dec)
+		}
+		return walkArray(dec, func() error {
+			var spans testSpans
+			err := walkObject(dec, func(key string) error {
+				switch key {
+				case "request":
+					return walkObject(dec, func(key string) error {
+						if key != "id" {
+							return skipValue(dec)
+						}
+						var err error
+						spans.id, err = readString(dec, data)
+						return err
+					})
+				case "depends_on":
+					return walkArray(dec, func() error {
+						dep, err := readString(dec, data)
+						spans.dependsOn = append(spans.dependsOn, dep)
+						return err
+					})
+				}
+				return skipValue(dec)
+			})
+			tests = append(tests, spans)
+			return err
+		})
+	})
+	return tests, err
+}
+
+// walkObject reads a JSON object from dec, calling fn with each key; fn must read the
+// key's value
+func walkObject(dec *json.Decoder, fn func(key string) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if err := fn(tok.(string)); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// walkArray reads a JSON array from dec, calling fn for each element; fn must read the
+// element
+func walkArray(dec *json.Decoder, fn func() error) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+// expectDelim reads the next token from dec, which must be the delimiter want
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("expected %v at offset %d, got %v", want, dec.InputOffset(), tok)
+	}
+	return nil
+}
+
+// skipValue reads the next JSON value from dec and discards it
+func skipValue(dec *json.Decoder) error {
+	var discard json.RawMessage
+	return dec.Decode(&discard)
+}
+
+// readString reads the next JSON value from dec, which must be a string, and returns it
+// with its location in data, the input of dec
+func readString(dec *json.Decoder, data []byte) (stringValue, error) {
+	// The offset precedes any whitespace and separators before the value
+	offset := int(dec.InputOffset())
+	tok, err := dec.Token()
+	if err != nil {
+		return stringValue{}, err
+	}
+	value, ok := tok.(string)
+	if !ok {
+		return stringValue{}, fmt.Errorf("expected string at offset %d, got %v", offset, tok)
+	}
+	start := offset + bytes.IndexByte(data[offset:], '"')
+	return stringValue{span: span{start: start, end: int(dec.InputOffset())}, value: value}, nil
+}
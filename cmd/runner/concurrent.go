@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// runSuitesConcurrently runs up to concurrency non-stateful suites at a time, each on a
+// clone of testRunner with a handler from a shared pool (or its own handler, for
+// in-process handlers). The stateful suites run afterwards, one by one on testRunner.
+// Results are returned in the order of suites, regardless of the order in which the
+// suites finished.
+// Once the failed tests trigger a stop (see suiteOptions.stopReason), no further suites
+// are started.
+func runSuitesConcurrently(ctx context.Context, testRunner *runner.TestRunner, suites []loadedSuite, reporter Reporter, opts suiteOptions) []runner.TestResult {
+	results := make([]runner.TestResult, len(suites))
+	ran := make([]bool, len(suites))
+	reporter = &lockedReporter{Reporter: reporter}
+
+	var failed atomic.Int64
+	stopReason := func() string {
+		return opts.stopReason(int(failed.Load()))
+	}
+
+	pool, err := testRunner.NewHandlerPool(opts.concurrency)
+	if err != nil {
+		slog.Warn("Handler pool unavailable, spawning a handler per suite", "error", err)
+	}
+
+	var stateful []int
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.concurrency)
+	for i, loaded := range suites {
+		if loaded.suite.IsStateful() {
+			stateful = append(stateful, i)
+			continue
+		}
+		sem <- struct{}{}
+		if stopReason() != "" {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			clone := testRunner.Clone()
+			defer clone.CloseHandler()
+			// Without a pooled handler, e.g. once ctx is done, the clone spawns its own
+			// handler and its tests report the failure
+			if release, err := clone.AcquireHandler(ctx, pool); err == nil {
+				defer release()
+			}
+			results[i] = runSuite(ctx, clone, loaded, reporter, opts.RunOptions)
+			ran[i] = true
+			failed.Add(int64(results[i].FailedTests))
+		}()
+	}
+	wg.Wait()
+	if pool != nil {
+		pool.Close()
+	}
+
+	for _, i := range stateful {
+		if stopReason() != "" {
+			break
+		}
+		results[i] = runSuite(ctx, testRunner, suites[i], reporter, opts.RunOptions)
+		ran[i] = true
+		failed.Add(int64(results[i].FailedTests))
+	}
+
+	var ordered []runner.TestResult
+	for i, result := range results {
+		if ran[i] {
+			ordered = append(ordered, result)
+		}
+	}
+	if reason := stopReason(); reason != "" {
+		printStop(reason, len(suites)-len(ordered))
+	}
+	return ordered
+}
+
+// lockedReporter serializes the calls to a reporter shared by concurrently running suites
+type lockedReporter struct {
+	mu sync.Mutex
+	Reporter
+}
+
+func (r *lockedReporter) SuiteStarted(testFile string, suite *runner.TestSuite) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Reporter.SuiteStarted(testFile, suite)
+}
+
+func (r *lockedReporter) TestFinished(suite *runner.TestSuite, result runner.SingleTestResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Reporter.TestFinished(suite, result)
+}
+
+func (r *lockedReporter) SuiteFinished(suite *runner.TestSuite, result runner.TestResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Reporter.SuiteFinished(suite, result)
+}
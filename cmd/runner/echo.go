@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// echoHandlerName is the --handler value that selects the built-in echo handler
+const echoHandlerName = ":echo"
+
+// echoHandler is a built-in handler that answers every request, whatever its method,
+// with the request params as the result. It never returns an error response, which
+// makes it useful for exercising the runner's own validation logic without a handler
+// binary.
+type echoHandler struct {
+	pending [][]byte
+}
+
+// SendLine decodes a request line and queues a response echoing its params
+func (h *echoHandler) SendLine(line []byte) error {
+	var req runner.Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return fmt.Errorf("failed to unmarshal request: %w", err)
+	}
+
+	respData, err := json.Marshal(runner.Response{Result: runner.Result(req.Params)})
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+	h.pending = append(h.pending, respData)
+	return nil
+}
+
+// ReadLine returns the oldest queued response
+func (h *echoHandler) ReadLine() ([]byte, error) {
+	if len(h.pending) == 0 {
+		return nil, runner.ErrHandlerClosed
+	}
+	line := h.pending[0]
+	h.pending = h.pending[1:]
+	return line, nil
+}
+
+// Close discards any queued responses
+func (h *echoHandler) Close() {
+	h.pending = nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// loadFingerprints reads a fingerprint file written by --record-fingerprints. The file
+// maps each test fingerprint to whether the test was expected to pass.
+func loadFingerprints(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fingerprint file: %w", err)
+	}
+
+	var known map[string]bool
+	if err := json.Unmarshal(data, &known); err != nil {
+		return nil, fmt.Errorf("failed to parse fingerprint file: %w", err)
+	}
+	return known, nil
+}
+
+// checkFingerprints logs a warning for every test whose outcome differs from the outcome
+// recorded for its fingerprint. Tests without a recorded fingerprint are ignored. Returns
+// the number of tests whose outcome changed.
+func checkFingerprints(results []runner.TestResult, known map[string]bool) int {
+	changed := 0
+	for _, result := range results {
+		for _, tr := range result.TestResults {
+			if tr.Disabled || tr.Skipped || tr.Fingerprint == "" {
+				continue
+			}
+			expectedPass, ok := known[tr.Fingerprint]
+			if !ok || expectedPass == tr.Passed {
+				continue
+			}
+			changed++
+			slog.Warn("test outcome differs from recorded fingerprint", "suite", tr.SuiteName,
+				"test", tr.TestID, "fingerprint", tr.Fingerprint, "expected_pass", expectedPass, "passed", tr.Passed)
+		}
+	}
+	return changed
+}
+
+// writeFingerprints writes the fingerprint and outcome of every test that ran to path,
+// in the format read by loadFingerprints
+func writeFingerprints(path string, results []runner.TestResult) error {
+	fingerprints := make(map[string]bool)
+	for _, result := range results {
+		for _, tr := range result.TestResults {
+			if tr.Disabled || tr.Skipped || tr.Fingerprint == "" {
+				continue
+			}
+			fingerprints[tr.Fingerprint] = tr.Passed
+		}
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create fingerprint file: %w", err)
+	}
+	defer out.Close()
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(fingerprints); err != nil {
+		return fmt.Errorf("failed to write fingerprints: %w", err)
+	}
+	return out.Close()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+)
+
+// listedTest is an entry of the test inventory written by --list --format=json
+type listedTest struct {
+	File        string   `json:"file"`
+	Suite       string   `json:"suite"`
+	ID          string   `json:"id"`
+	Description string   `json:"description,omitempty"`
+	Disabled    bool     `json:"disabled,omitempty"`
+	Skipped     bool     `json:"skipped,omitempty"`
+	SkipReason  string   `json:"skip_reason,omitempty"`
+	Tags        []string `json:"tags,omitempty"` // The tags of the test and of its suite
+}
+
+// listTests returns the tests of the suites in file order
+func listTests(suites []loadedSuite) []listedTest {
+	var tests []listedTest
+	for _, loaded := range suites {
+		for _, test := range loaded.suite.Tests {
+			tests = append(tests, listedTest{
+				File:        loaded.file,
+				Suite:       loaded.suite.Name,
+				ID:          test.Request.ID,
+				Description: test.Description,
+				Disabled:    test.Disabled,
+				Skipped:     test.Skip,
+				SkipReason:  test.SkipReason,
+				Tags:        slices.Concat(loaded.suite.Tags, test.Tags),
+			})
+		}
+	}
+	return tests
+}
+
+// writeTestListJSON writes the test inventory as indented JSON
+func writeTestListJSON(w io.Writer, tests []listedTest) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if tests == nil {
+		tests = []listedTest{}
+	}
+	return enc.Encode(tests)
+}
+
+// printTestList prints the test inventory as a table with one row per test
+func printTestList(w io.Writer, tests []listedTest) {
+	rows := [][]string{{"Suite", "Test", "Description"}}
+	for _, test := range tests {
+		description := test.Description
+		if test.Disabled {
+			description = strings.TrimSpace(description + " (disabled)")
+		}
+		switch {
+		case test.Skipped && test.SkipReason != "":
+			description = strings.TrimSpace(description + " (skipped: " + test.SkipReason + ")")
+		case test.Skipped:
+			description = strings.TrimSpace(description + " (skipped)")
+		}
+		rows = append(rows, []string{test.Suite, test.ID, description})
+	}
+
+	widths := columnWidths(rows)
+	for i, row := range rows {
+		printRow(w, row, widths)
+		if i == 0 {
+			printSeparator(w, widths)
+		}
+	}
+}
@@ -1,40 +1,268 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"io/fs"
+	"log/slog"
 	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/stringintech/kernel-bindings-tests/runner"
+	"github.com/stringintech/kernel-bindings-tests/runner/report"
 	"github.com/stringintech/kernel-bindings-tests/testdata"
 )
 
 func main() {
-	handlerPath := pflag.String("handler", "", "Path to handler binary")
+	handlerPath := pflag.String("handler", "", "Path to handler binary, auto:<name> to look up <name> in PATH, or :echo for the built-in echo handler")
 	handlerTimeout := pflag.Duration("handler-timeout", 10*time.Second, "Max time to wait for handler to respond to each test case (e.g., 10s, 500ms)")
+	handlerCloseTimeout := pflag.Duration("handler-close-timeout", 5*time.Second, "Max time to wait for the handler to exit after its stdin is closed before killing it (e.g., 5s, 200ms)")
+	handlerBufferSize := pflag.Int("handler-buffer-size", runner.MaxLineSize, "Max size in bytes of a handler response line, at most 64 MiB (e.g., 1048576)")
+	handlerReadySignal := pflag.String("handler-ready-signal", "", "Wait until the handler writes this line to stdout, or a line matching it as a regular expression, before sending requests (e.g., ready)")
 	timeout := pflag.Duration("timeout", 30*time.Second, "Total timeout for executing all test suites (e.g., 30s, 1m)")
 	verboseCount := pflag.CountP("verbose", "v", "Verbose mode: -v shows all requests needed to reproduce failed tests, plus received/expected responses; -vv shows this for all tests (passed and failed)")
+	restartPolicyName := pflag.String("handler-restart-policy", "never", "When to restart the handler: never, on-failure (after any failed test of non-stateful suites), after-suite, or always (before every test of non-stateful suites)")
+	checkCompliance := pflag.Bool("check-compliance", false, "Run built-in protocol compliance checks against the handler before running test suites")
+	format := pflag.String("format", formatText, "Output format (alias: --output): text, json, markdown (GitHub-Flavored Markdown for pull request comments), or tap (TAP version 13)")
+	reportURL := pflag.String("report-url", "", "POST the JSON results to this URL after all test suites complete (with --format=markdown, posts {\"body\": <markdown>} instead)")
+	reportAuth := pflag.String("report-auth", "", "HTTP Basic auth credentials for --report-url (user:pass)")
+	recordRequests := pflag.String("record-requests", "", "Record mode: read a JSON array of requests from this file, send them to the handler, and write a test suite built from the responses to --record-output")
+	recordOutput := pflag.String("record-output", "", "Output file for the test suite recorded with --record-requests")
+	eventLog := pflag.String("event-log", "", "Write test progress events as newline-delimited JSON to this file")
+	recordSession := pflag.String("record", "", "Write every request sent to the handler, with the response, outcome, and duration of its test, as newline-delimited JSON to this file")
+	replaySession := pflag.String("replay", "", "Run the requests of a --record session file instead of the test suites, failing those whose response differs from the recorded one")
+	excludeSuites := pflag.StringArray("exclude-suite", nil, "Skip test suites whose name or file name matches this glob pattern (repeatable, e.g., --exclude-suite='*mainnet*')")
+	excludePatterns := pflag.StringArray("exclude", nil, "Skip tests whose ID matches this glob pattern, and in stateful suites the tests depending on them (repeatable, e.g., --exclude='chain#2*')")
+	noSummaryTable := pflag.Bool("no-summary-table", false, "Do not print the per-suite summary table before the total summary")
+	metricsAddr := pflag.String("metrics-addr", "", "Serve Prometheus metrics at /metrics on this address (e.g., :9090) while the test suites run")
+	isolateGroups := pflag.Bool("isolate-groups", false, "Run each test group (group field) of non-stateful suites against its own handler process")
+	enforceSLOs := pflag.Bool("enforce-slos", false, "Fail tests that exceed their expected_response_time instead of only warning")
+	streaming := pflag.Bool("streaming", false, "Send all requests of parallel suites without waiting for responses, for handlers that answer concurrently and out of order")
+	bufferOutput := pflag.Bool("buffer-output", false, "Collect text output in memory and write it at once after all suites have run, for runs where console output is a bottleneck")
+	appendLogs := pflag.Bool("append-logs", false, "Append to suite log files (log_file) instead of truncating them on each run")
+	checkFingerprintsPath := pflag.String("check-fingerprints", "", "Warn when a test's outcome differs from the outcome recorded for its fingerprint in this file")
+	manifestPath := pflag.String("manifest", "", "Run the test suites against every handler listed in this JSON manifest and print a comparison report (replaces --handler)")
+	compareHandler := pflag.String("compare-handler", "", "Also run the test suites against this handler and report the tests whose results differ from those of --handler, e.g. to check a new binding against a reference implementation")
+	list := pflag.Bool("list", false, "List the test cases of all test suites without running them (no --handler needed); with --format=json, writes a JSON inventory")
+	suitePatterns := pflag.StringSlice("suite", nil, "Only run test suite files whose base name matches one of these glob patterns (comma-separated or repeatable, e.g., --suite='chain*.json')")
+	filter := pflag.String("filter", "", "Only run tests whose ID matches this glob pattern (e.g., --filter='chain#1*'); stateful suites keep the tests the matching tests depend on")
+	filterTagNames := pflag.StringArray("filter-tag", nil, "Only run tests with this tag, or in a suite with this tag (repeatable; tests with any of the tags run, e.g., --filter-tag=slow); stateful suites keep the tests the remaining tests depend on")
+	since := pflag.String("since", "", "Only run tests added in this suite version or later (added_in_version); tests without a version always run")
+	junitOutput := pflag.String("junit-output", "", "Write a JUnit XML report of all test suites to this file after they complete")
+	recordFingerprintsPath := pflag.String("record-fingerprints", "", "Write the fingerprint and outcome of every test to this file for use with --check-fingerprints")
+	noColor := pflag.Bool("no-color", false, "Do not color the text output, even on terminals that support it (also disabled by setting NO_COLOR)")
+	failFast := pflag.Bool("fail-fast", false, "Stop at the first failed test and skip all remaining tests and test suites")
+	retries := pflag.Int("retry", 0, "Run failed tests up to N more times before counting them as failed; stateful suites are run again from the beginning with a fresh handler")
+	concurrency := pflag.Int("concurrency", 1, "Run up to N non-stateful test suites at the same time, each with its own handler; stateful suites run one by one afterwards")
+	shard := pflag.Int("shard", 0, "Only run the tests assigned to this shard, from 0 to --total-shards minus 1")
+	totalShards := pflag.Int("total-shards", 1, "Split the tests into this many shards, e.g. to distribute them across parallel CI jobs (see --shard)")
+	seed := pflag.Int64("seed", 0, "Shuffle the order of the test suites and of the tests within non-stateful suites using this random seed (default: alphabetical suite order and file order of tests)")
+	maxFailures := pflag.Int("max-failures", 0, "Stop once more than N tests have failed, checked after each test suite; remaining test suites are not run (0: no limit)")
+	watch := pflag.Bool("watch", false, "Run the test suites again whenever the handler binary changes, until interrupted (Ctrl+C)")
+	compact := pflag.Bool("compact", false, "With --format=json, write the JSON results on a single line instead of pretty-printing them")
+	// --output is accepted as an alias of --format
+	pflag.CommandLine.SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "output" {
+			name = "format"
+		}
+		return pflag.NormalizedName(name)
+	})
 	pflag.Parse()
 
 	// Convert verbose count to verbosity level
-	verbosity := runner.VerbosityQuiet
+	runOpts := runner.RunOptions{FailFast: *failFast, Retries: *retries}
 	if *verboseCount >= 2 {
-		verbosity = runner.VerbosityAlways
+		runOpts.Verbosity = runner.VerbosityAlways
 	} else if *verboseCount == 1 {
-		verbosity = runner.VerbosityOnFailure
+		runOpts.Verbosity = runner.VerbosityOnFailure
+	}
+
+	var manifest []manifestHandler
+	if *manifestPath != "" {
+		if *handlerPath != "" || *recordRequests != "" || *checkCompliance || *format != formatText {
+			fmt.Fprintf(os.Stderr, "Error: --manifest cannot be combined with --handler, --record-requests, --check-compliance, or a --format other than text\n")
+			os.Exit(1)
+		}
+		var err error
+		manifest, err = loadManifest(*manifestPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if !*list {
+		if *handlerPath == "" {
+			fmt.Fprintf(os.Stderr, "Error: --handler flag is required\n")
+			pflag.Usage()
+			os.Exit(1)
+		}
+
+		resolvedHandlerPath, err := resolveHandlerPath(*handlerPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		*handlerPath = resolvedHandlerPath
+	}
+
+	// A comparison runs like a manifest of the two handlers
+	if *compareHandler != "" {
+		if *manifestPath != "" || *recordRequests != "" || *list || *watch || *replaySession != "" || *format != formatText {
+			fmt.Fprintf(os.Stderr, "Error: --compare-handler cannot be combined with --manifest, --record-requests, --list, --watch, --replay, or a --format other than text\n")
+			os.Exit(1)
+		}
+		resolvedComparePath, err := resolveHandlerPath(*compareHandler)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		manifest = []manifestHandler{
+			{Path: *handlerPath, Label: "primary"},
+			{Path: resolvedComparePath, Label: "comparison"},
+		}
+	}
+
+	restartPolicy, err := runner.ParseRestartPolicy(*restartPolicyName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --handler-restart-policy: %v\n", err)
+		os.Exit(1)
+	}
+	if *handlerBufferSize < 1 || *handlerBufferSize > runner.MaxLineSize {
+		fmt.Fprintf(os.Stderr, "Error: --handler-buffer-size must be between 1 and %d bytes\n", runner.MaxLineSize)
+		os.Exit(1)
+	}
+
+	if *format != formatText && *format != formatJSON && *format != formatMarkdown && *format != formatTAP {
+		fmt.Fprintf(os.Stderr, "Error: unsupported --format %q (expected text, json, markdown, or tap)\n", *format)
+		os.Exit(1)
+	}
+
+	if *concurrency < 1 {
+		fmt.Fprintf(os.Stderr, "Error: --concurrency must be at least 1\n")
+		os.Exit(1)
+	}
+
+	if *totalShards < 1 || *shard < 0 || *shard >= *totalShards {
+		fmt.Fprintf(os.Stderr, "Error: --shard must be between 0 and --total-shards minus 1, and --total-shards at least 1\n")
+		os.Exit(1)
+	}
+
+	if *maxFailures < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --max-failures must not be negative\n")
+		os.Exit(1)
+	}
+	suiteOpts := suiteOptions{RunOptions: runOpts, concurrency: *concurrency, maxFailures: *maxFailures}
+
+	if *replaySession != "" && (*manifestPath != "" || *recordRequests != "" || *list) {
+		fmt.Fprintf(os.Stderr, "Error: --replay cannot be combined with --manifest, --record-requests, or --list\n")
+		os.Exit(1)
+	}
+
+	if *watch && (*manifestPath != "" || *recordRequests != "" || *list || *handlerPath == echoHandlerName) {
+		fmt.Fprintf(os.Stderr, "Error: --watch requires a handler binary and cannot be combined with --manifest, --record-requests, or --list\n")
+		os.Exit(1)
+	}
+
+	if *retries < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --retry must not be negative\n")
+		os.Exit(1)
+	}
+
+	if (*recordRequests == "") != (*recordOutput == "") {
+		fmt.Fprintf(os.Stderr, "Error: --record-requests and --record-output must be used together\n")
+		os.Exit(1)
 	}
 
-	if *handlerPath == "" {
-		fmt.Fprintf(os.Stderr, "Error: --handler flag is required\n")
-		pflag.Usage()
+	if *reportAuth != "" && !strings.Contains(*reportAuth, ":") {
+		fmt.Fprintf(os.Stderr, "Error: --report-auth must be in user:pass form\n")
 		os.Exit(1)
 	}
 
+	for _, pattern := range *suitePatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --suite pattern %q: %v\n", pattern, err)
+			os.Exit(1)
+		}
+	}
+
+	for _, pattern := range *excludeSuites {
+		if _, err := path.Match(pattern, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --exclude-suite pattern %q: %v\n", pattern, err)
+			os.Exit(1)
+		}
+	}
+
+	for _, pattern := range *excludePatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --exclude pattern %q: %v\n", pattern, err)
+			os.Exit(1)
+		}
+	}
+
+	if *since != "" {
+		if err := runner.ValidateVersionRange(*since, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --since: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *filter != "" {
+		if _, err := path.Match(*filter, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --filter pattern %q: %v\n", *filter, err)
+			os.Exit(1)
+		}
+	}
+
+	var knownFingerprints map[string]bool
+	if *checkFingerprintsPath != "" {
+		knownFingerprints, err = loadFingerprints(*checkFingerprintsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// With --seed, the order of suites and tests is shuffled after they are selected.
+	// The seed is printed so that a failing order can be reproduced.
+	shuffle := func(suites []loadedSuite) {}
+	if pflag.CommandLine.Changed("seed") {
+		fmt.Fprintf(os.Stderr, "Shuffling test order with --seed=%d\n", *seed)
+		shuffle = func(suites []loadedSuite) {
+			shuffleSuites(suites, *seed)
+		}
+	}
+
+	// selectTests applies the test selection flags to suites, in the order: --since,
+	// --filter, --filter-tag, --exclude, --shard, and --seed. With announce, excluded tests
+	// are reported on stderr. Returns the selected suites and the number of excluded tests.
+	selectTests := func(suites []loadedSuite, announce bool) ([]loadedSuite, int) {
+		suites = dropTestsAddedBefore(suites, *since)
+		suites = filterTests(suites, *filter)
+		suites = filterTags(suites, *filterTagNames)
+		suites, excludedTests := excludeTests(suites, *excludePatterns, announce)
+		suites = shardTests(suites, *shard, *totalShards)
+		shuffle(suites)
+		return suites, excludedTests
+	}
+
+	// selectSuites loads the suites of testFiles, leaving out those matching
+	// --exclude-suite, and selects their tests with selectTests. With announce, excluded
+	// suites and tests are reported on stderr. Returns the selected suites, the names of
+	// the excluded suites, and the number of excluded tests.
+	selectSuites := func(testFiles []string, announce bool) ([]loadedSuite, []string, int) {
+		suites, excludedSuites := loadSuites(testFiles, *excludeSuites, announce)
+		suites, excludedTests := selectTests(suites, announce)
+		return suites, excludedSuites, excludedTests
+	}
+
 	// Collect embedded test files
 	testFiles, err := fs.Glob(testdata.FS, "*.json")
 	if err != nil {
@@ -47,87 +275,671 @@ func main() {
 		os.Exit(1)
 	}
 
+	if len(*suitePatterns) > 0 {
+		testFiles = slices.DeleteFunc(testFiles, func(testFile string) bool {
+			return !matchesAny(path.Base(testFile), *suitePatterns)
+		})
+		if len(testFiles) == 0 {
+			fmt.Fprintf(os.Stderr, "No test files match --suite %s\n", strings.Join(*suitePatterns, ","))
+			os.Exit(1)
+		}
+	}
+
 	// Sort test files alphabetically for deterministic execution order
 	sort.Strings(testFiles)
 
-	// Create test runner
-	testRunner, err := runner.NewTestRunner(*handlerPath, *handlerTimeout, *timeout)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating test runner: %v\n", err)
+	if *list {
+		suites, _, _ := selectSuites(testFiles, false)
+		tests := listTests(suites)
+		if *format == formatJSON {
+			if err := writeTestListJSON(os.Stdout, tests); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing test list: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			printTestList(os.Stdout, tests)
+		}
+		return
+	}
+
+	// With --buffer-output, text output is collected and written once the suites have run
+	var out io.Writer = os.Stdout
+	var outBuf bytes.Buffer
+	if *bufferOutput {
+		out = &outBuf
+	}
+	flushOutput := func() {
+		os.Stdout.Write(outBuf.Bytes())
+		outBuf.Reset()
+	}
+
+	var reporters multiReporter
+	if *format == formatText {
+		color := !*noColor && report.ColorEnabled(os.Stdout)
+		reporters = append(reporters, textReporter{w: out, progress: os.Stderr, color: color})
+	}
+	if *eventLog != "" {
+		f, err := os.Create(*eventLog)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating event log: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		reporters = append(reporters, newEventLogReporter(f))
+	}
+	if *recordSession != "" {
+		f, err := os.Create(*recordSession)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating session file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		reporters = append(reporters, newSessionRecorder(f))
+	}
+
+	stopMetrics := func() {}
+	if *metricsAddr != "" {
+		metrics := newMetricsReporter()
+		stopMetrics, err = serveMetrics(*metricsAddr, metrics)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting metrics server: %v\n", err)
+			os.Exit(1)
+		}
+		reporters = append(reporters, metrics)
+	}
+
+	var reporter Reporter = reporters
+	// Annotate failures inline in GitHub pull requests. Workflow commands are written to
+	// stdout, so they are only emitted alongside the text output.
+	if os.Getenv("GITHUB_ACTIONS") == "true" && *format == formatText {
+		reporter = newGitHubActionsReporter(reporters, out)
+	}
+
+	if *checkCompliance && *handlerPath == echoHandlerName {
+		fmt.Fprintf(os.Stderr, "Error: --check-compliance requires a handler binary, not %s\n", echoHandlerName)
 		os.Exit(1)
 	}
-	defer testRunner.CloseHandler()
+	if *checkCompliance {
+		errs := runner.CheckProtocolCompliance(context.Background(), runner.HandlerConfig{
+			Path:         *handlerPath,
+			Timeout:      *handlerTimeout,
+			CloseTimeout: *handlerCloseTimeout,
+			BufferSize:   *handlerBufferSize,
+			ReadySignal:  *handlerReadySignal,
+		})
+		if len(errs) > 0 {
+			fmt.Fprintf(os.Stderr, "Handler failed %d protocol compliance check(s):\n", len(errs))
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "  ✗ %v\n", err)
+			}
+			os.Exit(1)
+		}
+		if *format == formatText {
+			fmt.Printf("Handler passed all protocol compliance checks\n")
+		}
+	}
 
-	// Create context with total execution timeout
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
-	defer cancel()
+	// newTestRunner creates a test runner for a handler binary, or for the built-in echo
+	// handler, with the configured options
+	newTestRunner := func(handlerPath string) (*runner.TestRunner, error) {
+		var testRunner *runner.TestRunner
+		var err error
+		if handlerPath == echoHandlerName {
+			testRunner, err = runner.NewTestRunnerWithHandler(func() (runner.HandlerInterface, error) {
+				return &echoHandler{}, nil
+			}, *timeout)
+		} else {
+			testRunner, err = runner.NewTestRunner(handlerPath, *handlerTimeout, *timeout)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error creating test runner: %w", err)
+		}
+		testRunner.SetRestartPolicy(restartPolicy)
+		testRunner.SetHandlerCloseTimeout(*handlerCloseTimeout)
+		testRunner.SetHandlerBufferSize(*handlerBufferSize)
+		testRunner.SetHandlerReadySignal(*handlerReadySignal)
+		testRunner.SetAppendLogs(*appendLogs)
+		testRunner.SetIsolateGroups(*isolateGroups)
+		testRunner.SetEnforceSLOs(*enforceSLOs)
+		testRunner.SetStreaming(*streaming)
+		// In watch mode, a run is canceled when the handler changes
+		testRunner.SetAbortOnContextCancel(*watch)
+		return testRunner, nil
+	}
 
-	// Run tests
-	totalPassed := 0
-	totalFailed := 0
-	totalTests := 0
+	if manifest != nil {
+		suites, _, _ := selectSuites(testFiles, true)
+		var allResults [][]runner.TestResult
+		allPassed := true
+		for _, h := range manifest {
+			fmt.Printf("\n##### Handler: %s (%s) #####\n", h.Label, h.Path)
+			testRunner, err := newTestRunner(h.Path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+			results := runSuites(ctx, testRunner, suites, reporter, suiteOpts)
+			cancel()
+			flushOutput()
+			testRunner.CloseHandler()
 
-	for _, testFile := range testFiles {
-		fmt.Printf("\n=== Running test suite: %s ===\n", testFile)
+			allResults = append(allResults, results)
+			if newRunSummary(results).FailedTests > 0 {
+				allPassed = false
+			}
+		}
+		stopMetrics()
+
+		fmt.Printf("\n")
+		if *compareHandler != "" {
+			diffs := runner.CompareResults(runner.MergeTestResults(allResults[0]...), runner.MergeTestResults(allResults[1]...))
+			printResultDifferences(os.Stdout, manifest[0], manifest[1], diffs)
+			if len(diffs) > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+		printManifestComparison(os.Stdout, manifest, allResults)
+		if !allPassed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *recordRequests != "" {
+		testRunner, err := newTestRunner(*handlerPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		err = recordSuite(ctx, testRunner, *recordRequests, *recordOutput)
+		cancel()
+		testRunner.CloseHandler()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error recording test suite: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Recorded test suite written to %s\n", *recordOutput)
+		return
+	}
+
+	// runAllSuites runs the selected test suites against a fresh test runner for
+	// handlerPath and reports the results. Returns the exit code: 1 if a test failed or
+	// the results could not be written.
+	runAllSuites := func(ctx context.Context, handlerPath string) int {
+		testRunner, err := newTestRunner(handlerPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer testRunner.CloseHandler()
+
+		// Limit the total execution time
+		ctx, cancel := context.WithTimeout(ctx, *timeout)
+		defer cancel()
+
+		// Load all test suites upfront so that remaining work is known while running
+		var suites []loadedSuite
+		var excluded []string
+		var excludedTests int
+		if *replaySession != "" {
+			if suites, err = loadSession(*replaySession); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			suites, excludedTests = selectTests(suites, *format == formatText)
+		} else {
+			suites, excluded, excludedTests = selectSuites(testFiles, *format == formatText)
+		}
+
+		// Run tests
+		results := runSuites(ctx, testRunner, suites, reporter, suiteOpts)
+		flushOutput()
+
+		if knownFingerprints != nil {
+			if changed := checkFingerprints(results, knownFingerprints); changed > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: %d test outcome(s) differ from %s\n", changed, *checkFingerprintsPath)
+			}
+		}
+		if *recordFingerprintsPath != "" {
+			if err := writeFingerprints(*recordFingerprintsPath, results); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+		}
+
+		if *junitOutput != "" {
+			if err := writeJUnitFile(*junitOutput, results); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+		}
+
+		summary := newRunSummary(results)
+		summary.ExcludedSuites = excluded
+		summary.ExcludedTests = excludedTests
+
+		var posted any = summary
+		switch *format {
+		case formatJSON:
+			if err := writeJSONSummary(os.Stdout, summary, *compact); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing JSON results: %v\n", err)
+				return 1
+			}
+		case formatTAP:
+			if err := report.WriteTAP(os.Stdout, results); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing TAP results: %v\n", err)
+				return 1
+			}
+		case formatMarkdown:
+			markdown := runner.TestResults(results).SummaryMarkdown()
+			fmt.Print(markdown)
+			posted = markdownReport{Body: markdown}
+		default:
+			if !*noSummaryTable {
+				fmt.Printf("\n")
+				printSummaryTable(os.Stdout, results)
+			}
+
+			fmt.Printf("\n" + strings.Repeat("=", 60) + "\n")
+			fmt.Printf("TOTAL SUMMARY\n")
+			fmt.Printf(strings.Repeat("=", 60) + "\n")
+			fmt.Printf("Total Tests: %d\n", summary.TotalTests)
+			fmt.Printf("Passed:      %d\n", summary.PassedTests)
+			if summary.PassedOnRetry > 0 {
+				fmt.Printf("  on retry:  %d\n", summary.PassedOnRetry)
+			}
+			fmt.Printf("Failed:      %d\n", summary.FailedTests)
+			if summary.DisabledTests > 0 {
+				fmt.Printf("Disabled:    %d\n", summary.DisabledTests)
+			}
+			if summary.SkippedTests > 0 {
+				fmt.Printf("Skipped:     %d\n", summary.SkippedTests)
+			}
+			if summary.ExcludedTests > 0 {
+				fmt.Printf("Excluded:    %d\n", summary.ExcludedTests)
+			}
+			for _, name := range excluded {
+				fmt.Printf("Suite:       %s (excluded)\n", name)
+			}
+			fmt.Printf(strings.Repeat("=", 60) + "\n")
+		}
+
+		// Report delivery failures are warnings only; the exit code reflects test results
+		if *reportURL != "" {
+			if err := postReport(*reportURL, *reportAuth, posted); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to post results to %s: %v\n", *reportURL, err)
+			}
+		}
+
+		if summary.FailedTests > 0 {
+			return 1
+		}
+		return 0
+	}
+
+	if *watch {
+		err := watchHandler(*handlerPath, func(ctx context.Context) {
+			runAllSuites(ctx, *handlerPath)
+		})
+		stopMetrics()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	exitCode := runAllSuites(context.Background(), *handlerPath)
+	stopMetrics()
+	os.Exit(exitCode)
+}
+
+// resolveHandlerPath resolves the --handler value. A value of the form auto:<name> is
+// looked up in the directories listed in PATH; any other value is returned unchanged.
+func resolveHandlerPath(handler string) (string, error) {
+	name, ok := strings.CutPrefix(handler, "auto:")
+	if !ok {
+		return handler, nil
+	}
+	if name == "" {
+		return "", fmt.Errorf("--handler auto: requires a binary name (e.g., auto:kernel-handler)")
+	}
 
+	resolved, err := exec.LookPath(name)
+	if err != nil {
+		var searched strings.Builder
+		for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+			fmt.Fprintf(&searched, "\n  %s", dir)
+		}
+		return "", fmt.Errorf("handler binary %q not found in PATH. Searched:%s", name, searched.String())
+	}
+	return resolved, nil
+}
+
+// loadedSuite is a test suite together with the file it was loaded from
+type loadedSuite struct {
+	file  string
+	suite *runner.TestSuite
+}
+
+// loadSuites loads the given embedded suite files, skipping files that fail to load and
+// suites whose name or file name matches an exclude pattern. Returns the loaded suites
+// and the names of the excluded ones.
+func loadSuites(testFiles []string, excludePatterns []string, announceExcluded bool) ([]loadedSuite, []string) {
+	var suites []loadedSuite
+	var excluded []string
+	var all []runner.TestSuite
+	for _, testFile := range testFiles {
 		// Load test suite from embedded FS
 		suite, err := runner.LoadTestSuiteFromFS(testdata.FS, testFile)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading test suite: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error loading test suite %s: %v\n", testFile, err)
 			continue
 		}
+		all = append(all, *suite)
+		if orphaned, err := suite.OrphanedRefs(); err == nil && len(orphaned) > 0 {
+			slog.Warn("suite creates refs that no test uses", "suite", testFile, "refs", orphaned)
+		}
 
-		// Run suite
-		result := testRunner.RunTestSuite(ctx, *suite, verbosity)
-		printResults(suite, result)
+		if matchesAny(suite.Name, excludePatterns) || matchesAny(path.Base(testFile), excludePatterns) {
+			if announceExcluded {
+				fmt.Printf("\n=== Skipping excluded test suite: %s ===\n", testFile)
+			}
+			excluded = append(excluded, suite.Name)
+			continue
+		}
 
-		totalPassed += result.PassedTests
-		totalFailed += result.FailedTests
-		totalTests += result.TotalTests
+		suites = append(suites, loadedSuite{file: testFile, suite: suite})
+	}
 
-		// Close handler after stateful suites to prevent state leaks.
-		// A new handler process will be spawned on-demand when the next request is sent.
-		if suite.Stateful {
-			testRunner.CloseHandler()
+	// Excluded suites count too, as tests are looked up by ID across all suites
+	if err := runner.ValidateSuiteIDs(all); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: duplicate test IDs:\n%v\n", err)
+		os.Exit(1)
+	}
+	return suites, excluded
+}
+
+// dropTestsAddedBefore removes the tests added in a suite version earlier than since,
+// and the suites left without tests. Returns the suites unchanged if since is empty.
+func dropTestsAddedBefore(suites []loadedSuite, since string) []loadedSuite {
+	if since == "" {
+		return suites
+	}
+
+	var kept []loadedSuite
+	for _, loaded := range suites {
+		var tests []runner.TestCase
+		for _, test := range loaded.suite.Tests {
+			if !test.AddedBefore(since) {
+				tests = append(tests, test)
+			}
 		}
+		if len(tests) == 0 {
+			continue
+		}
+		loaded.suite.Tests = tests
+		kept = append(kept, loaded)
 	}
+	return kept
+}
 
-	fmt.Printf("\n" + strings.Repeat("=", 60) + "\n")
-	fmt.Printf("TOTAL SUMMARY\n")
-	fmt.Printf(strings.Repeat("=", 60) + "\n")
-	fmt.Printf("Total Tests: %d\n", totalTests)
-	fmt.Printf("Passed:      %d\n", totalPassed)
-	fmt.Printf("Failed:      %d\n", totalFailed)
-	fmt.Printf(strings.Repeat("=", 60) + "\n")
+// filterTests keeps only the tests whose ID matches pattern (see TestSuite.FilterTests)
+// and drops the suites left without tests. A note is printed for every suite with
+// excluded tests. Returns the suites unchanged if pattern is empty.
+func filterTests(suites []loadedSuite, pattern string) []loadedSuite {
+	if pattern == "" {
+		return suites
+	}
+	return filterSuites(suites, "--filter", func(suite *runner.TestSuite) (int, error) {
+		return suite.FilterTests(pattern)
+	})
+}
 
-	if totalFailed > 0 {
-		os.Exit(1)
+// filterTags keeps only the tests with any of tags (see TestSuite.FilterTags) and drops
+// the suites left without tests, like filterTests. Returns the suites unchanged if no
+// tags are given.
+func filterTags(suites []loadedSuite, tags []string) []loadedSuite {
+	if len(tags) == 0 {
+		return suites
 	}
+	return filterSuites(suites, "--filter-tag", func(suite *runner.TestSuite) (int, error) {
+		return suite.FilterTags(tags)
+	})
 }
 
-func printResults(suite *runner.TestSuite, result runner.TestResult) {
-	fmt.Printf("\nTest Suite: %s\n", result.SuiteName)
-	if suite.Description != "" {
-		fmt.Printf("Description: %s\n", suite.Description)
+// filterSuites removes tests from every suite with filter, which returns the number of
+// tests it removed, and drops the suites left without tests. A note naming flag is
+// printed for every suite with removed tests.
+func filterSuites(suites []loadedSuite, flag string, filter func(suite *runner.TestSuite) (int, error)) []loadedSuite {
+	var kept []loadedSuite
+	for _, loaded := range suites {
+		total := len(loaded.suite.Tests)
+		removed, err := filter(loaded.suite)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error filtering test suite %s: %v\n", loaded.file, err)
+			continue
+		}
+		if removed == total {
+			continue
+		}
+		if removed > 0 {
+			fmt.Fprintf(os.Stderr, "Note: %s excluded %d of %d tests of test suite %s\n", flag, removed, total, loaded.file)
+		}
+		kept = append(kept, loaded)
+	}
+	return kept
+}
+
+// suiteOptions configures how runSuites runs the suites
+type suiteOptions struct {
+	runner.RunOptions
+	// concurrency is the number of non-stateful suites run at the same time
+	concurrency int
+	// maxFailures stops the run once more tests than this have failed; 0 means no limit
+	maxFailures int
+}
+
+// stopReason returns why no further suites are run once failed tests have failed in
+// total, or "" if the run continues
+func (o suiteOptions) stopReason(failed int) string {
+	switch {
+	case o.FailFast && failed > 0:
+		return "Stopping after the first failure (--fail-fast)"
+	case o.maxFailures > 0 && failed > o.maxFailures:
+		return fmt.Sprintf("Stopping after %d failed tests (--max-failures=%d)", failed, o.maxFailures)
+	}
+	return ""
+}
+
+// excludeTests removes the tests whose ID matches any of the patterns (see
+// TestSuite.ExcludeTests) and drops the suites left without tests. With announce, a
+// line is printed for every excluded test. Returns the number of excluded tests.
+func excludeTests(suites []loadedSuite, patterns []string, announce bool) ([]loadedSuite, int) {
+	if len(patterns) == 0 {
+		return suites, 0
+	}
+
+	var kept []loadedSuite
+	count := 0
+	for _, loaded := range suites {
+		var excluded []string
+		for _, pattern := range patterns {
+			ids, err := loaded.suite.ExcludeTests(pattern)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error excluding tests of test suite %s: %v\n", loaded.file, err)
+				break
+			}
+			excluded = append(excluded, ids...)
+		}
+		if announce && len(excluded) > 0 {
+			fmt.Printf("\n=== Excluded tests of test suite: %s ===\n", loaded.file)
+			for _, id := range excluded {
+				fmt.Printf("  ~ %s (excluded)\n", id)
+			}
+		}
+		count += len(excluded)
+		if len(loaded.suite.Tests) > 0 {
+			kept = append(kept, loaded)
+		}
+	}
+	return kept, count
+}
+
+// runSuites runs the suites in order, notifying the reporter, and returns their results.
+// The suites after the one that triggered a stop (see suiteOptions.stopReason) are not
+// run. With a concurrency above 1, see runSuitesConcurrently.
+func runSuites(ctx context.Context, testRunner *runner.TestRunner, suites []loadedSuite, reporter Reporter, opts suiteOptions) []runner.TestResult {
+	if opts.concurrency > 1 {
+		return runSuitesConcurrently(ctx, testRunner, suites, reporter, opts)
+	}
+
+	var results []runner.TestResult
+	failed := 0
+	for i, loaded := range suites {
+		result := runSuite(ctx, testRunner, loaded, reporter, opts.RunOptions)
+		results = append(results, result)
+
+		failed += result.FailedTests
+		if reason := opts.stopReason(failed); reason != "" {
+			printStop(reason, len(suites)-i-1)
+			break
+		}
+		if eta := estimateRemaining(suites[i+1:]); eta > 0 {
+			fmt.Fprintf(os.Stderr, "ETA: %s remaining\n", eta)
+		}
+	}
+	return results
+}
+
+// runSuite runs a single suite, notifying the reporter, and returns its result. Suites
+// that name their own handler or handler environment run against a separate runner
+// for it.
+func runSuite(ctx context.Context, testRunner *runner.TestRunner, loaded loadedSuite, reporter Reporter, opts runner.RunOptions) runner.TestResult {
+	testFile, suite := loaded.file, loaded.suite
+	reporter.SuiteStarted(testFile, suite)
+
+	if suite.Handler != "" {
+		testRunner = testRunner.WithHandler(suite.Handler, suite.HandlerArgs)
+		defer testRunner.CloseHandler()
+	}
+	if len(suite.HandlerEnv) > 0 {
+		testRunner = testRunner.WithHandlerEnv(suite.HandlerEnv)
+		defer testRunner.CloseHandler()
+	}
+
+	// Run suite, notifying the reporter of every test as soon as it completes
+	testRunner.SetProgress(func(testResult runner.SingleTestResult) {
+		reporter.TestFinished(suite, testResult)
+	})
+	result := testRunner.RunTestSuite(ctx, *suite, opts)
+	testRunner.SetProgress(nil)
+	reporter.SuiteFinished(suite, result)
+
+	// Close handler after stateful suites to prevent state leaks.
+	// A new handler process will be spawned on-demand when the next request is sent.
+	if suite.IsStateful() {
+		testRunner.CloseHandler()
 	}
-	fmt.Printf("Total: %d, Passed: %d, Failed: %d\n\n", result.TotalTests, result.PassedTests, result.FailedTests)
 
-	for i, tr := range result.TestResults {
-		status := "✓"
+	if estimate := suite.EstimatedDurationValue(); estimate > 0 && result.Duration > 2*estimate {
+		slog.Warn("suite took longer than estimated", "suite", suite.Name,
+			"estimated", estimate, "actual", result.Duration.Round(time.Millisecond))
+	}
+	return result
+}
+
+// printStop notes why the run stopped early and how many suites were not run
+func printStop(reason string, remaining int) {
+	if remaining > 0 {
+		fmt.Fprintf(os.Stderr, "%s, %d test suite(s) not run\n", reason, remaining)
+	}
+}
+
+// estimateRemaining sums the estimated durations of the given suites.
+// Suites without an estimate contribute nothing.
+func estimateRemaining(suites []loadedSuite) time.Duration {
+	var total time.Duration
+	for _, loaded := range suites {
+		total += loaded.suite.EstimatedDurationValue()
+	}
+	return total
+}
+
+// matchesAny reports whether name matches any of the given glob patterns.
+// Patterns are validated upfront, so match errors are not expected here.
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// printResults prints a suite result. The suite provides the suite description and may
+// be nil for results merged from several suites; test descriptions come from the results.
+// With color, the lines of passed tests are printed in green and those of failed tests in
+// red.
+func printResults(w io.Writer, suite *runner.TestSuite, result runner.TestResult, color bool) {
+	fmt.Fprintf(w, "\nTest Suite: %s\n", result.SuiteName)
+	if suite != nil && suite.Description != "" {
+		fmt.Fprintf(w, "Description: %s\n", suite.Description)
+	}
+	fmt.Fprintf(w, "Total: %d, Passed: %d, Failed: %d", result.TotalTests, result.PassedTests, result.FailedTests)
+	if result.DisabledTests > 0 {
+		fmt.Fprintf(w, ", Disabled: %d", result.DisabledTests)
+	}
+	if result.SkippedTests > 0 {
+		fmt.Fprintf(w, ", Skipped: %d", result.SkippedTests)
+	}
+	if result.Retries > 0 {
+		fmt.Fprintf(w, ", Suite retries: %d", result.Retries)
+	}
+	fmt.Fprintf(w, "\n\n")
+
+	if result.Skipped {
+		fmt.Fprintf(w, "  Suite skipped: %s\n\n", result.SkipReason)
+		return
+	}
+
+	for _, tr := range result.TestResults {
+		// Results merged from several suites are prefixed with their own suite name
+		testID := tr.TestID
+		if tr.SuiteName != result.SuiteName {
+			testID = tr.SuiteName + "::" + tr.TestID
+		}
+
+		if tr.Disabled {
+			fmt.Fprintf(w, "  - %s (disabled)\n", testID)
+			continue
+		}
+		if tr.Skipped {
+			fmt.Fprintf(w, "  ~ %s: %s\n", testID, tr.Message)
+			continue
+		}
+
+		status, statusColor := "✓", report.ColorGreen
 		if !tr.Passed {
-			status = "✗"
+			status, statusColor = "✗", report.ColorRed
 		}
 
 		// Print test ID and description if available
-		if suite.Tests[i].Description != "" {
-			fmt.Printf("  %s %s (%s)\n", status, tr.TestID, suite.Tests[i].Description)
-		} else {
-			fmt.Printf("  %s %s\n", status, tr.TestID)
+		line := fmt.Sprintf("%s %s", status, testID)
+		if tr.Description != "" {
+			line += fmt.Sprintf(" (%s)", tr.Description)
+		}
+		if tr.Passed && tr.Retries > 0 {
+			line += fmt.Sprintf(" (passed on retry %d)", tr.Retries)
 		}
+		fmt.Fprintf(w, "  %s\n", report.Colorize(line, statusColor, color))
 
 		// Print message indented
-		fmt.Printf("      %s\n", tr.Message)
+		fmt.Fprintf(w, "      %s\n", tr.Message)
 	}
 
-	fmt.Printf("\n")
+	fmt.Fprintf(w, "\n")
 }
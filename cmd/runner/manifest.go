@@ -0,0 +1,188 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// manifestHandler is a handler entry of a --manifest file
+type manifestHandler struct {
+	Path  string `json:"path"`
+	Label string `json:"label"`
+	// SHA256 optionally pins the handler binary to a hex-encoded SHA-256 digest
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// loadManifest reads a handler manifest, resolves auto:<name> paths, and verifies that
+// every entry has a unique label and, if pinned, the expected SHA-256 digest
+func loadManifest(manifestPath string) ([]manifestHandler, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var handlers []manifestHandler
+	if err := json.Unmarshal(data, &handlers); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if len(handlers) == 0 {
+		return nil, fmt.Errorf("manifest lists no handlers")
+	}
+
+	labels := make(map[string]bool)
+	for i := range handlers {
+		h := &handlers[i]
+		if h.Path == "" || h.Label == "" {
+			return nil, fmt.Errorf("manifest entry %d: path and label are required", i)
+		}
+		if labels[h.Label] {
+			return nil, fmt.Errorf("manifest entry %d: duplicate label %q", i, h.Label)
+		}
+		labels[h.Label] = true
+
+		resolved, err := resolveHandlerPath(h.Path)
+		if err != nil {
+			return nil, fmt.Errorf("handler %s: %w", h.Label, err)
+		}
+		h.Path = resolved
+
+		if h.SHA256 != "" {
+			sum, err := fileSHA256(h.Path)
+			if err != nil {
+				return nil, fmt.Errorf("handler %s: %w", h.Label, err)
+			}
+			if !strings.EqualFold(sum, h.SHA256) {
+				return nil, fmt.Errorf("handler %s: sha256 mismatch: expected %s, got %s", h.Label, h.SHA256, sum)
+			}
+		}
+	}
+	return handlers, nil
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 digest of a file
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// printManifestComparison prints one row per test and one column per handler showing
+// whether the handler passed (✓), failed (✗), or did not run (-) the test, followed by
+// each handler's pass count
+func printManifestComparison(w io.Writer, handlers []manifestHandler, results [][]runner.TestResult) {
+	// Collect test keys in run order, across all handlers
+	var keys []string
+	seen := make(map[string]bool)
+	status := make([]map[string]string, len(handlers))
+	for i, handlerResults := range results {
+		status[i] = make(map[string]string)
+		for _, suiteResult := range handlerResults {
+			for _, tr := range suiteResult.TestResults {
+				key := tr.SuiteName + "::" + tr.TestID
+				if !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+				switch {
+				case tr.Disabled || tr.Skipped:
+					status[i][key] = "-"
+				case tr.Passed:
+					status[i][key] = "✓"
+				default:
+					status[i][key] = "✗"
+				}
+			}
+		}
+	}
+
+	header := []string{"Test"}
+	for _, h := range handlers {
+		header = append(header, h.Label)
+	}
+	rows := [][]string{header}
+	for _, key := range keys {
+		row := []string{key}
+		for i := range handlers {
+			cell := status[i][key]
+			if cell == "" {
+				cell = "-"
+			}
+			row = append(row, cell)
+		}
+		rows = append(rows, row)
+	}
+	totals := []string{"Passed"}
+	for i := range handlers {
+		summary := newRunSummary(results[i])
+		totals = append(totals, fmt.Sprintf("%d/%d", summary.PassedTests, summary.TotalTests))
+	}
+	rows = append(rows, totals)
+
+	widths := columnWidths(rows)
+	for i, row := range rows {
+		if i == len(rows)-1 {
+			printSeparator(w, widths)
+		}
+		printRow(w, row, widths)
+		if i == 0 {
+			printSeparator(w, widths)
+		}
+	}
+}
+
+// columnWidths returns the width in runes of the widest cell of every column
+func columnWidths(rows [][]string) []int {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+	return widths
+}
+
+// printRow prints a table row with the cells padded to the column widths
+func printRow(w io.Writer, row []string, widths []int) {
+	cells := make([]string, len(row))
+	for j, cell := range row {
+		cells[j] = cell + strings.Repeat(" ", widths[j]-len([]rune(cell)))
+	}
+	fmt.Fprintf(w, "%s\n", strings.TrimRight(strings.Join(cells, " | "), " "))
+}
+
+// printSeparator prints a table separator line for the given column widths
+func printSeparator(w io.Writer, widths []int) {
+	separators := make([]string, len(widths))
+	for j, width := range widths {
+		separators[j] = strings.Repeat("-", width)
+	}
+	fmt.Fprintf(w, "%s\n", strings.Join(separators, "-+-"))
+}
+
+// printResultDifferences prints the tests whose results differ between the primary and
+// the comparison handler of --compare-handler
+func printResultDifferences(w io.Writer, primary, comparison manifestHandler, diffs []runner.ResultDifference) {
+	if len(diffs) == 0 {
+		fmt.Fprintf(w, "No differences between %s and %s\n", primary.Path, comparison.Path)
+		return
+	}
+	fmt.Fprintf(w, "%d test(s) differ between %s (primary) and %s (comparison):\n", len(diffs), primary.Path, comparison.Path)
+	for _, diff := range diffs {
+		fmt.Fprintf(w, "  ~ %s::%s: %s\n", diff.SuiteName, diff.TestID, diff.Description)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the test duration histogram
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metricsReporter collects test run metrics and serves them in the Prometheus text
+// exposition format
+type metricsReporter struct {
+	mu        sync.Mutex
+	tests     map[[2]string]int // (suite, status) -> count
+	durations map[[2]string]*histogram
+	restarts  map[string]int // suite -> count
+}
+
+// histogram holds cumulative bucket counts, matching durationBuckets
+type histogram struct {
+	buckets []int
+	count   int
+	sum     float64
+}
+
+func newMetricsReporter() *metricsReporter {
+	return &metricsReporter{
+		tests:     make(map[[2]string]int),
+		durations: make(map[[2]string]*histogram),
+		restarts:  make(map[string]int),
+	}
+}
+
+func (m *metricsReporter) SuiteStarted(testFile string, suite *runner.TestSuite) {}
+
+func (m *metricsReporter) TestFinished(suite *runner.TestSuite, result runner.SingleTestResult) {
+	status := "passed"
+	switch {
+	case result.Disabled:
+		status = "disabled"
+	case result.Skipped:
+		status = "skipped"
+	case !result.Passed:
+		status = "failed"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tests[[2]string{suite.Name, status}]++
+	if result.Disabled || result.Skipped {
+		return
+	}
+
+	key := [2]string{suite.Name, result.TestID}
+	h, ok := m.durations[key]
+	if !ok {
+		h = &histogram{buckets: make([]int, len(durationBuckets))}
+		m.durations[key] = h
+	}
+	seconds := result.Duration.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+func (m *metricsReporter) SuiteFinished(suite *runner.TestSuite, result runner.TestResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.restarts[suite.Name] += result.HandlerRestarts
+}
+
+// ServeHTTP writes all metrics in the Prometheus text exposition format
+func (m *metricsReporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	fmt.Fprintf(w, "# HELP conformance_tests_total Number of tests run, by suite and status.\n")
+	fmt.Fprintf(w, "# TYPE conformance_tests_total counter\n")
+	for _, key := range sortedKeys(m.tests) {
+		fmt.Fprintf(w, "conformance_tests_total{suite=%s,status=%s} %d\n",
+			promLabel(key[0]), promLabel(key[1]), m.tests[key])
+	}
+
+	fmt.Fprintf(w, "# HELP conformance_test_duration_seconds Time spent running each test.\n")
+	fmt.Fprintf(w, "# TYPE conformance_test_duration_seconds histogram\n")
+	for _, key := range sortedKeys(m.durations) {
+		h := m.durations[key]
+		labels := fmt.Sprintf("suite=%s,test_id=%s", promLabel(key[0]), promLabel(key[1]))
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(w, "conformance_test_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(bound, 'g', -1, 64), h.buckets[i])
+		}
+		fmt.Fprintf(w, "conformance_test_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(w, "conformance_test_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "conformance_test_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	fmt.Fprintf(w, "# HELP conformance_handler_restarts_total Number of handler restarts, by suite.\n")
+	fmt.Fprintf(w, "# TYPE conformance_handler_restarts_total counter\n")
+	suites := make([]string, 0, len(m.restarts))
+	for suite := range m.restarts {
+		suites = append(suites, suite)
+	}
+	sort.Strings(suites)
+	for _, suite := range suites {
+		fmt.Fprintf(w, "conformance_handler_restarts_total{suite=%s} %d\n", promLabel(suite), m.restarts[suite])
+	}
+}
+
+// serveMetrics starts serving the reporter's metrics at /metrics on addr. The listener
+// is opened before returning so that address errors are reported immediately. The
+// returned function shuts the server down gracefully.
+func serveMetrics(addr string, m *metricsReporter) (func(), error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}, nil
+}
+
+// sortedKeys returns the label pairs of a metric in sorted order for stable output
+func sortedKeys[V any](m map[[2]string]V) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
+
+// promLabel quotes a label value for the Prometheus text format
+func promLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
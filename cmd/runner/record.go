@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// recordSuite reads a JSON array of requests from requestsPath, records the handler's
+// responses to them, and writes the resulting test suite to outputPath. The suite name
+// is derived from the output filename.
+func recordSuite(ctx context.Context, testRunner *runner.TestRunner, requestsPath, outputPath string) error {
+	data, err := os.ReadFile(requestsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read requests file: %w", err)
+	}
+
+	var requests []runner.Request
+	if err := json.Unmarshal(data, &requests); err != nil {
+		return fmt.Errorf("failed to parse requests file: %w", err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+	suite, err := testRunner.RecordSuite(ctx, requests, name)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer out.Close()
+
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return fmt.Errorf("failed to write suite: %w", err)
+	}
+	return out.Close()
+}
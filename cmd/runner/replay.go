@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// loadSession reads a --record session file and turns it into one suite per recorded
+// suite, in the order the suites first appear. Each recorded request becomes a test
+// that expects the recorded response, so that running the suites compares a handler
+// against the recorded one. Records without a response are skipped. Suites that store
+// object references run in dependency order against a single handler, as their
+// requests depend on each other.
+func loadSession(sessionPath string) ([]loadedSuite, error) {
+	f, err := os.Open(sessionPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session file: %w", err)
+	}
+	defer f.Close()
+
+	var suites []loadedSuite
+	index := make(map[string]int)
+	skipped := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, runner.MaxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record sessionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("session file line %d: %w", line, err)
+		}
+		if record.Request == nil {
+			return nil, fmt.Errorf("session file line %d: missing request", line)
+		}
+		if record.Response == nil {
+			skipped++
+			continue
+		}
+
+		i, ok := index[record.Suite]
+		if !ok {
+			i = len(suites)
+			index[record.Suite] = i
+			suites = append(suites, loadedSuite{file: sessionPath, suite: &runner.TestSuite{Name: record.Suite}})
+		}
+		suite := suites[i].suite
+		suite.Tests = append(suite.Tests, runner.TestCase{
+			Request:          *record.Request,
+			ExpectedResponse: *record.Response,
+		})
+		if record.Request.Ref != "" {
+			suite.ExecutionModel = runner.ExecutionDependencyOrdered
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+
+	if len(suites) == 0 {
+		return nil, fmt.Errorf("session file %s has no requests with a response to replay", sessionPath)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "Note: %d recorded request(s) without a response are not replayed\n", skipped)
+	}
+	return suites, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+	"github.com/stringintech/kernel-bindings-tests/runner/report"
+)
+
+const (
+	formatText     = "text"
+	formatJSON     = "json"
+	formatMarkdown = "markdown"
+	formatTAP      = "tap"
+)
+
+// runSummary is the machine-readable result of a complete run across all test suites.
+// It is written to stdout with --format=json and posted to --report-url (unless
+// --format=markdown is used, see markdownReport).
+type runSummary struct {
+	Passed        bool                `json:"passed"`
+	TotalTests    int                 `json:"total_tests"`
+	PassedTests   int                 `json:"passed_tests"`
+	FailedTests   int                 `json:"failed_tests"`
+	DisabledTests int                 `json:"disabled_tests,omitempty"`
+	SkippedTests  int                 `json:"skipped_tests,omitempty"`
+	PassedOnRetry int                 `json:"passed_on_retry,omitempty"` // Passed tests that failed at least once first, see --retry
+	Suites        []runner.TestResult `json:"suites"`
+
+	// FailureCategories counts validation errors by reason across all suites
+	FailureCategories map[string]int `json:"failure_categories,omitempty"`
+
+	// ExcludedSuites lists the names of suites skipped via --exclude-suite
+	ExcludedSuites []string `json:"excluded_suites,omitempty"`
+
+	// ExcludedTests counts the tests skipped via --exclude; they are not part of the total
+	ExcludedTests int `json:"excluded_tests,omitempty"`
+}
+
+// newRunSummary aggregates suite results into a run summary
+func newRunSummary(results []runner.TestResult) runSummary {
+	summary := runSummary{Suites: results}
+	for _, result := range results {
+		summary.TotalTests += result.TotalTests
+		summary.PassedTests += result.PassedTests
+		summary.FailedTests += result.FailedTests
+		summary.DisabledTests += result.DisabledTests
+		summary.SkippedTests += result.SkippedTests
+		for _, testResult := range result.TestResults {
+			if testResult.Passed && testResult.Retries > 0 {
+				summary.PassedOnRetry++
+			}
+		}
+		for _, validationErr := range result.ValidationErrors {
+			if summary.FailureCategories == nil {
+				summary.FailureCategories = make(map[string]int)
+			}
+			summary.FailureCategories[validationErr.Reason]++
+		}
+	}
+	summary.Passed = summary.FailedTests == 0
+	return summary
+}
+
+// writeJSONSummary writes the run summary as indented JSON, or on a single line if
+// compact is set
+func writeJSONSummary(w io.Writer, summary runSummary, compact bool) error {
+	enc := json.NewEncoder(w)
+	if !compact {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(summary)
+}
+
+// writeJUnitFile writes the results as a JUnit XML report to path
+func writeJUnitFile(path string, results []runner.TestResult) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create JUnit report: %w", err)
+	}
+	defer out.Close()
+
+	if err := report.WriteJUnit(out, results); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// markdownReport is posted to --report-url with --format=markdown. Its shape matches the
+// GitHub issue comment API, so the URL can point directly at a pull request's comments.
+type markdownReport struct {
+	Body string `json:"body"`
+}
+
+// postReport sends the report as JSON to the given URL. If auth is non-empty it must be
+// in user:pass form and is sent as HTTP Basic auth. Returns an error if the request
+// fails or the server responds with a non-2xx status.
+func postReport(url, auth string, report any) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth != "" {
+		user, pass, _ := strings.Cut(auth, ":")
+		req.SetBasicAuth(user, pass)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return nil
+}
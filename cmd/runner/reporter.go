@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// Reporter receives notifications as test suites are run
+type Reporter interface {
+	// SuiteStarted is called before the first test of a suite runs
+	SuiteStarted(testFile string, suite *runner.TestSuite)
+	// TestFinished is called once for every test result in a suite
+	TestFinished(suite *runner.TestSuite, result runner.SingleTestResult)
+	// SuiteFinished is called after all tests of a suite have run
+	SuiteFinished(suite *runner.TestSuite, result runner.TestResult)
+}
+
+// multiReporter fans out notifications to several reporters in order
+type multiReporter []Reporter
+
+func (m multiReporter) SuiteStarted(testFile string, suite *runner.TestSuite) {
+	for _, r := range m {
+		r.SuiteStarted(testFile, suite)
+	}
+}
+
+func (m multiReporter) TestFinished(suite *runner.TestSuite, result runner.SingleTestResult) {
+	for _, r := range m {
+		r.TestFinished(suite, result)
+	}
+}
+
+func (m multiReporter) SuiteFinished(suite *runner.TestSuite, result runner.TestResult) {
+	for _, r := range m {
+		r.SuiteFinished(suite, result)
+	}
+}
+
+// textReporter prints human-readable results, normally to stdout, and a progress line
+// for every test as soon as it completes, normally to stderr
+type textReporter struct {
+	w        io.Writer
+	progress io.Writer
+	color    bool // Whether to color passed and failed tests
+}
+
+func (r textReporter) SuiteStarted(testFile string, suite *runner.TestSuite) {
+	fmt.Fprintf(r.w, "\n=== Running test suite: %s ===\n", testFile)
+}
+
+func (r textReporter) TestFinished(suite *runner.TestSuite, result runner.SingleTestResult) {
+	switch {
+	case r.progress == nil || result.Disabled || result.Skipped:
+	case result.Passed && result.Retries > 0:
+		fmt.Fprintf(r.progress, "✓ %s (passed on retry %d)\n", result.TestID, result.Retries)
+	case result.Passed:
+		fmt.Fprintf(r.progress, "✓ %s\n", result.TestID)
+	default:
+		// Verbose output is only included in the full report
+		message, _, _ := strings.Cut(result.Message, "\n")
+		fmt.Fprintf(r.progress, "✗ %s: %s\n", result.TestID, message)
+	}
+}
+
+func (r textReporter) SuiteFinished(suite *runner.TestSuite, result runner.TestResult) {
+	printResults(r.w, suite, result, r.color)
+}
+
+// eventLogReporter writes one JSON object per event (NDJSON) so a run can be
+// monitored in real time, e.g. with `tail -f events.ndjson | jq .`
+type eventLogReporter struct {
+	enc *json.Encoder
+}
+
+func newEventLogReporter(w io.Writer) *eventLogReporter {
+	return &eventLogReporter{enc: json.NewEncoder(w)}
+}
+
+type suiteStartEvent struct {
+	Type  string    `json:"type"`
+	Suite string    `json:"suite"`
+	Time  time.Time `json:"time"`
+}
+
+type testEndEvent struct {
+	Type       string `json:"type"`
+	Suite      string `json:"suite"`
+	ID         string `json:"id"`
+	Passed     bool   `json:"passed"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type suiteEndEvent struct {
+	Type   string    `json:"type"`
+	Suite  string    `json:"suite"`
+	Passed int       `json:"passed"`
+	Failed int       `json:"failed"`
+	Time   time.Time `json:"time"`
+}
+
+func (r *eventLogReporter) SuiteStarted(testFile string, suite *runner.TestSuite) {
+	r.write(suiteStartEvent{
+		Type:  "suite_start",
+		Suite: suite.Name,
+		Time:  time.Now(),
+	})
+}
+
+func (r *eventLogReporter) TestFinished(suite *runner.TestSuite, result runner.SingleTestResult) {
+	if result.Disabled || result.Skipped {
+		return
+	}
+	r.write(testEndEvent{
+		Type:       "test_end",
+		Suite:      suite.Name,
+		ID:         result.TestID,
+		Passed:     result.Passed,
+		DurationMs: result.Duration.Milliseconds(),
+	})
+}
+
+func (r *eventLogReporter) SuiteFinished(suite *runner.TestSuite, result runner.TestResult) {
+	r.write(suiteEndEvent{
+		Type:   "suite_end",
+		Suite:  suite.Name,
+		Passed: result.PassedTests,
+		Failed: result.FailedTests,
+		Time:   time.Now(),
+	})
+}
+
+// write encodes a single event. Write errors are ignored so that a broken event log
+// never interrupts the test run.
+func (r *eventLogReporter) write(event any) {
+	_ = r.enc.Encode(event)
+}
+
+// githubActionsReporter wraps another reporter and additionally emits a GitHub Actions
+// ::error workflow command for every failed test, which GitHub shows as an inline
+// annotation on the suite file.
+type githubActionsReporter struct {
+	Reporter
+	w           io.Writer
+	currentFile string
+}
+
+func newGitHubActionsReporter(inner Reporter, w io.Writer) *githubActionsReporter {
+	return &githubActionsReporter{Reporter: inner, w: w}
+}
+
+func (r *githubActionsReporter) SuiteStarted(testFile string, suite *runner.TestSuite) {
+	r.currentFile = testFile
+	r.Reporter.SuiteStarted(testFile, suite)
+}
+
+func (r *githubActionsReporter) TestFinished(suite *runner.TestSuite, result runner.SingleTestResult) {
+	r.Reporter.TestFinished(suite, result)
+	if result.Passed || result.Disabled || result.Skipped {
+		return
+	}
+	fmt.Fprintf(r.w, "::error file=%s,title=%s::%s\n",
+		escapeGitHubProperty("testdata/"+r.currentFile),
+		escapeGitHubProperty(result.TestID),
+		escapeGitHubData(result.Message))
+}
+
+// escapeGitHubData escapes a workflow command message
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a workflow command property value
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// sessionRecord is a line of a --record session file: a request sent to the handler,
+// the response it returned, and the outcome of the test
+type sessionRecord struct {
+	Suite      string           `json:"suite"`
+	TestID     string           `json:"test_id"`
+	Request    *runner.Request  `json:"request"`
+	Response   *runner.Response `json:"response"` // null if no response was read
+	Passed     bool             `json:"passed"`
+	DurationMs int64            `json:"duration_ms"`
+}
+
+// sessionRecorder writes a sessionRecord (NDJSON) for every test that sent its request
+// to the handler, as an audit trail of what the handler received and returned
+type sessionRecorder struct {
+	enc *json.Encoder
+}
+
+func newSessionRecorder(w io.Writer) *sessionRecorder {
+	return &sessionRecorder{enc: json.NewEncoder(w)}
+}
+
+func (r *sessionRecorder) SuiteStarted(testFile string, suite *runner.TestSuite) {}
+
+func (r *sessionRecorder) TestFinished(suite *runner.TestSuite, result runner.SingleTestResult) {
+	// Disabled and skipped tests have no request
+	if result.Request == nil {
+		return
+	}
+	// Write errors are ignored so that a broken session file never interrupts the run
+	_ = r.enc.Encode(sessionRecord{
+		Suite:      suite.Name,
+		TestID:     result.TestID,
+		Request:    result.Request,
+		Response:   result.ReceivedResponse,
+		Passed:     result.Passed,
+		DurationMs: result.Duration.Milliseconds(),
+	})
+}
+
+func (r *sessionRecorder) SuiteFinished(suite *runner.TestSuite, result runner.TestResult) {}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// shardTests keeps only the tests assigned to shard (0-based) out of totalShards, and
+// drops the suites left without tests. The tests of all suites are sorted by ID, with
+// ties broken by suite file, and the test at index i is assigned to shard i % totalShards.
+// Stateful suites are kept whole if any of their tests is assigned to the shard, as
+// their tests depend on the state left by earlier ones. Returns the suites unchanged for
+// a single shard.
+func shardTests(suites []loadedSuite, shard, totalShards int) []loadedSuite {
+	if totalShards <= 1 {
+		return suites
+	}
+
+	type testRef struct {
+		id, file     string
+		suite, index int
+	}
+	var refs []testRef
+	for i, loaded := range suites {
+		for j, test := range loaded.suite.Tests {
+			refs = append(refs, testRef{id: test.Request.ID, file: loaded.file, suite: i, index: j})
+		}
+	}
+	slices.SortFunc(refs, func(a, b testRef) int {
+		return cmp.Or(cmp.Compare(a.id, b.id), cmp.Compare(a.file, b.file), cmp.Compare(a.index, b.index))
+	})
+
+	inShard := make([]map[int]bool, len(suites))
+	for i := range inShard {
+		inShard[i] = make(map[int]bool)
+	}
+	for i, ref := range refs {
+		if i%totalShards == shard {
+			inShard[ref.suite][ref.index] = true
+		}
+	}
+
+	var kept []loadedSuite
+	keptTests := 0
+	for i, loaded := range suites {
+		if len(inShard[i]) == 0 {
+			continue
+		}
+		if !loaded.suite.IsStateful() {
+			var tests []runner.TestCase
+			for j, test := range loaded.suite.Tests {
+				if inShard[i][j] {
+					tests = append(tests, test)
+				}
+			}
+			loaded.suite.Tests = tests
+		}
+		keptTests += len(loaded.suite.Tests)
+		kept = append(kept, loaded)
+	}
+	fmt.Fprintf(os.Stderr, "Note: shard %d of %d runs %d of %d tests\n", shard, totalShards, keptTests, len(refs))
+	return kept
+}
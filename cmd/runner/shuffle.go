@@ -0,0 +1,39 @@
+package main
+
+import (
+	"math/rand/v2"
+	"slices"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// shuffleSuites shuffles the order of the suites and of the tests within each
+// non-stateful suite, deterministically for a given seed. The tests of stateful suites
+// keep their order, as they depend on the state left by earlier ones. Within other
+// suites, a test still comes after the tests creating the refs it uses (see
+// runner.DependencyTracker.BuildExecutionOrder); suites whose dependencies cannot be
+// resolved keep their order.
+func shuffleSuites(suites []loadedSuite, seed int64) {
+	rng := rand.New(rand.NewPCG(uint64(seed), 0))
+	rng.Shuffle(len(suites), func(i, j int) {
+		suites[i], suites[j] = suites[j], suites[i]
+	})
+	for _, loaded := range suites {
+		if loaded.suite.IsStateful() {
+			continue
+		}
+		tests := loaded.suite.Tests
+		shuffled := slices.Clone(tests)
+		rng.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+
+		order, err := runner.NewDependencyTracker().BuildExecutionOrder(shuffled)
+		if err != nil {
+			continue
+		}
+		for k, i := range order {
+			tests[k] = shuffled[i]
+		}
+	}
+}
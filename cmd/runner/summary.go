@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// printSummaryTable prints a per-suite results table sorted by pass rate ascending,
+// so the most broken suites appear first. Numeric columns are right-aligned.
+func printSummaryTable(w io.Writer, results []runner.TestResult) {
+	sorted := slices.Clone(results)
+	slices.SortStableFunc(sorted, func(a, b runner.TestResult) int {
+		rateA, rateB := passRate(a), passRate(b)
+		switch {
+		case rateA < rateB:
+			return -1
+		case rateA > rateB:
+			return 1
+		}
+		return 0
+	})
+
+	header := []string{"Suite", "Total", "Passed", "Failed", "Pass%", "Duration"}
+	rows := [][]string{header}
+	for _, r := range sorted {
+		rows = append(rows, []string{
+			r.SuiteName,
+			fmt.Sprintf("%d", r.TotalTests),
+			fmt.Sprintf("%d", r.PassedTests),
+			fmt.Sprintf("%d", r.FailedTests),
+			formatPassRate(r),
+			r.Duration.Round(time.Millisecond).String(),
+		})
+	}
+
+	// Compute column widths from the widest entry
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			if j == 0 {
+				cells[j] = fmt.Sprintf("%-*s", widths[j], cell)
+			} else {
+				cells[j] = fmt.Sprintf("%*s", widths[j], cell)
+			}
+		}
+		fmt.Fprintf(w, "%s\n", strings.Join(cells, " | "))
+
+		if i == 0 {
+			separators := make([]string, len(widths))
+			for j, width := range widths {
+				separators[j] = strings.Repeat("-", width)
+			}
+			fmt.Fprintf(w, "%s\n", strings.Join(separators, "-+-"))
+		}
+	}
+}
+
+// passRate returns the percentage of passed tests in a suite. Suites without executed
+// tests are treated as fully passing, so that they sort last.
+func passRate(r runner.TestResult) float64 {
+	if r.TotalTests == 0 {
+		return 100
+	}
+	return float64(r.PassedTests) * 100 / float64(r.TotalTests)
+}
+
+// formatPassRate formats the pass rate of a suite for the summary table, or "n/a" if
+// none of its tests ran, e.g. because all of them were disabled or skipped.
+func formatPassRate(r runner.TestResult) string {
+	if r.TotalTests == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f%%", passRate(r))
+}
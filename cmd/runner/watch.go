@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchSettleDelay is how long the handler binary must be left unchanged after a change
+// before the tests are run again, so that a build writing it in several steps causes a
+// single run
+const watchSettleDelay = 200 * time.Millisecond
+
+// watchHandler calls run right away and again whenever the handler binary changes,
+// canceling the context of a run that is still in progress first. It returns once
+// interrupted (SIGINT), after the current run has been canceled.
+func watchHandler(handlerPath string, run func(ctx context.Context)) error {
+	target, err := filepath.Abs(handlerPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve handler path: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+	// Watch the directory rather than the binary itself, as builds often replace the
+	// binary, which would end a watch on the file
+	if err := watcher.Add(filepath.Dir(target)); err != nil {
+		return fmt.Errorf("failed to watch handler directory: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// isChange reports whether an event modifies the handler binary
+	isChange := func(event fsnotify.Event) bool {
+		return event.Name == target && event.Op&(fsnotify.Write|fsnotify.Create) != 0
+	}
+
+	for runs := 1; ; runs++ {
+		fmt.Printf("\n%s\n##### Watch run %d (%s) #####\n%s\n",
+			strings.Repeat("#", 60), runs, time.Now().Format(time.TimeOnly), strings.Repeat("#", 60))
+		runCtx, cancelRun := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			run(runCtx)
+		}()
+
+		// Wait for the handler to change, or for an interrupt
+		changed := false
+		for !changed {
+			select {
+			case <-ctx.Done():
+				cancelRun()
+				if done != nil {
+					<-done
+				}
+				return nil
+			case <-done:
+				fmt.Fprintf(os.Stderr, "Watching %s for changes (Ctrl+C to exit)\n", handlerPath)
+				done = nil
+			case event, ok := <-watcher.Events:
+				if !ok {
+					cancelRun()
+					return fmt.Errorf("file watcher closed")
+				}
+				changed = isChange(event)
+			case err, ok := <-watcher.Errors:
+				if ok {
+					slog.Warn("File watcher error", "error", err)
+				}
+			}
+		}
+
+		// Wait for the write to settle, restarting the delay on every further change
+		settle := time.NewTimer(watchSettleDelay)
+		for settling := true; settling; {
+			select {
+			case <-ctx.Done():
+				settle.Stop()
+				settling = false
+			case event := <-watcher.Events:
+				if isChange(event) {
+					settle.Reset(watchSettleDelay)
+				}
+			case <-settle.C:
+				settling = false
+			}
+		}
+
+		fmt.Fprintf(os.Stderr, "Handler %s changed, restarting test run\n", handlerPath)
+		cancelRun()
+		if done != nil {
+			<-done
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
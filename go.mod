@@ -2,4 +2,11 @@ module github.com/stringintech/kernel-bindings-tests
 
 go 1.23
 
-require github.com/spf13/pflag v1.0.10
+require (
+	github.com/fsnotify/fsnotify v1.10.1
+	github.com/spf13/pflag v1.0.10
+	golang.org/x/mod v0.22.0
+	golang.org/x/term v0.27.0
+)
+
+require golang.org/x/sys v0.28.0 // indirect
@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+)
+
+// SetAbortOnContextCancel controls whether a test waiting on the handler is aborted as
+// soon as the context passed to RunTestSuite is done (e.g., cancelled on SIGINT), by
+// killing the handler process. Otherwise, a pending read only ends when the handler
+// responds or the handler timeout elapses. Defaults to false.
+func (tr *TestRunner) SetAbortOnContextCancel(abort bool) {
+	tr.abortOnCancel = abort
+}
+
+// killHandlerOnDone kills the handler process once ctx is done, until the returned
+// function is called. The failed read or write then closes the handler as usual. The
+// handler is spawned if needed; handlers that do not run as a subprocess are left alone.
+func (tr *TestRunner) killHandlerOnDone(ctx context.Context) (stop func()) {
+	if err := tr.ensureHandler(); err != nil {
+		// Let SendRequest report the spawn failure
+		return func() {}
+	}
+	h, ok := tr.handler.(*Handler)
+	if !ok {
+		return func() {}
+	}
+	stopKill := context.AfterFunc(ctx, h.kill)
+	return func() { stopKill() }
+}
+
+// abortErr replaces err with the context's error if the handler was killed because the
+// context is done, as the handler's own error (e.g., closed stdout) would be misleading
+func (tr *TestRunner) abortErr(ctx context.Context, err error) error {
+	if tr.abortOnCancel && ctx.Err() != nil {
+		return fmt.Errorf("aborted: %w", ctx.Err())
+	}
+	return err
+}
@@ -0,0 +1,32 @@
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunTest_AbortOnContextCancel(t *testing.T) {
+	h, err := newHandlerForTest(t, helperNameUnresponsive, 10*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	tr := &TestRunner{handler: h}
+	defer tr.CloseHandler()
+	tr.SetAbortOnContextCancel(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	result := tr.runTest(ctx, &TestCase{Request: Request{ID: "1", Method: "test"}})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected test to abort shortly after cancellation, took %v", elapsed)
+	}
+	if result.Passed || result.Message != "Failed to read response: aborted: context canceled" {
+		t.Errorf("expected aborted test to fail, got passed=%v message=%q", result.Passed, result.Message)
+	}
+	if tr.handler != nil {
+		t.Errorf("expected handler to be closed after abort")
+	}
+}
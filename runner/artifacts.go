@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// suiteArtifacts writes the artifact directory of a suite (see TestSuite.ArtifactDir)
+type suiteArtifacts struct {
+	dir       string
+	requests  *os.File
+	responses *os.File
+	reqEnc    *json.Encoder
+	respEnc   *json.Encoder
+}
+
+// failedTestArtifact is the content of a file in the failed/ artifact subdirectory
+type failedTestArtifact struct {
+	Request  *Request  `json:"request"`
+	Response *Response `json:"response"`
+	Message  string    `json:"message,omitempty"`
+}
+
+// unsafeFileNameChars matches characters replaced when deriving file names from test IDs
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._#-]`)
+
+// openArtifacts creates the artifact directory of a suite and directs request/response
+// logging to it until closeArtifacts is called. Failed test files of a previous run are
+// removed.
+func (tr *TestRunner) openArtifacts(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "failed")); err != nil {
+		return fmt.Errorf("failed to remove previous failed test artifacts: %w", err)
+	}
+
+	requests, err := os.Create(filepath.Join(dir, "requests.ndjson"))
+	if err != nil {
+		return fmt.Errorf("failed to create requests artifact: %w", err)
+	}
+	responses, err := os.Create(filepath.Join(dir, "responses.ndjson"))
+	if err != nil {
+		requests.Close()
+		return fmt.Errorf("failed to create responses artifact: %w", err)
+	}
+
+	a := &suiteArtifacts{
+		dir:       dir,
+		requests:  requests,
+		responses: responses,
+		reqEnc:    json.NewEncoder(requests),
+		respEnc:   json.NewEncoder(responses),
+	}
+	a.reqEnc.SetEscapeHTML(false)
+	a.respEnc.SetEscapeHTML(false)
+	tr.artifacts = a
+	return nil
+}
+
+// closeArtifacts writes the suite result and one file per failed test to the artifact
+// directory, and closes the request and response logs
+func (tr *TestRunner) closeArtifacts(suite *TestSuite, result *TestResult) error {
+	a := tr.artifacts
+	tr.artifacts = nil
+	errs := []error{a.requests.Close(), a.responses.Close()}
+
+	errs = append(errs, writeJSONFile(filepath.Join(a.dir, "result.json"), result))
+
+	requests := make(map[string]*Request, len(suite.Tests))
+	for i := range suite.Tests {
+		requests[suite.Tests[i].Request.ID] = &suite.Tests[i].Request
+	}
+	for _, testResult := range result.TestResults {
+		if testResult.Passed || testResult.Disabled || testResult.Skipped {
+			continue
+		}
+		failedDir := filepath.Join(a.dir, "failed")
+		if err := os.MkdirAll(failedDir, 0o755); err != nil {
+			errs = append(errs, fmt.Errorf("failed to create failed test directory: %w", err))
+			break
+		}
+		name := unsafeFileNameChars.ReplaceAllString(testResult.TestID, "_") + ".json"
+		errs = append(errs, writeJSONFile(filepath.Join(failedDir, name), failedTestArtifact{
+			Request:  requests[testResult.TestID],
+			Response: testResult.ReceivedResponse,
+			Message:  testResult.Message,
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// writeJSONFile writes v to a file as indented JSON
+func writeJSONFile(path string, v any) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
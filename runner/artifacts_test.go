@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunTestSuite_ArtifactDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "artifacts", "suite")
+
+	// A stale file of a previous run must be removed
+	if err := os.MkdirAll(filepath.Join(dir, "failed"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "failed", "stale.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		return Response{Result: Result(`true`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	suite := TestSuite{
+		Name:        "Artifacts",
+		ArtifactDir: dir,
+		Tests: []TestCase{
+			{Request: Request{ID: "ok#1", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+			{Request: Request{ID: "bad/2", Method: "m"}, ExpectedResponse: Response{Result: Result(`false`)}},
+		},
+	}
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+
+	for _, name := range []string{"requests.ndjson", "responses.ndjson"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if lines := bytes.Count(data, []byte("\n")); lines != 2 {
+			t.Errorf("expected 2 lines in %s, got %d:\n%s", name, lines, data)
+		}
+	}
+
+	var written TestResult
+	data, err := os.ReadFile(filepath.Join(dir, "result.json"))
+	if err != nil {
+		t.Fatalf("failed to read result.json: %v", err)
+	}
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("failed to parse result.json: %v", err)
+	}
+	if written.SuiteName != result.SuiteName || written.FailedTests != 1 || written.Duration != result.Duration {
+		t.Errorf("result.json does not match the result: %+v", written)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "failed"))
+	if err != nil {
+		t.Fatalf("failed to read failed directory: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "bad_2.json" {
+		t.Fatalf("expected only bad_2.json in failed/, got %v", entries)
+	}
+	var failed failedTestArtifact
+	data, _ = os.ReadFile(filepath.Join(dir, "failed", "bad_2.json"))
+	if err := json.Unmarshal(data, &failed); err != nil {
+		t.Fatalf("failed to parse failed test artifact: %v", err)
+	}
+	if failed.Request == nil || failed.Request.ID != "bad/2" || failed.Response == nil || string(failed.Response.Result) != "true" {
+		t.Errorf("unexpected failed test artifact: %s", data)
+	}
+}
@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResultDifference describes a test whose outcome differs between two runs of the same
+// test suites, e.g. against two handlers
+type ResultDifference struct {
+	SuiteName string `json:"suite_name"`
+	TestID    string `json:"test_id"`
+	// A and B are the test's results in the two runs; nil if it did not run in that run
+	A *SingleTestResult `json:"a,omitempty"`
+	B *SingleTestResult `json:"b,omitempty"`
+	// Description contrasts the outcomes as "<outcome in a> vs <outcome in b>", e.g.
+	// "passed vs failed" or "failed: result_mismatch vs failed: unexpected_error"
+	Description string `json:"description"`
+}
+
+// CompareResults compares the results of two runs of the same test suites, matching
+// tests by suite name and test ID, and returns the tests whose outcomes differ, in the
+// order they ran in a followed by those that only ran in b. Outcomes differ if the test
+// passed in one run but not the other, was disabled or skipped in only one, or failed in both for
+// different reasons: a different validation error reason, a different error code in
+// the response, or no response in only one of the runs.
+func CompareResults(a, b TestResult) []ResultDifference {
+	type key struct{ suite, test string }
+	inB := make(map[key]*SingleTestResult, len(b.TestResults))
+	for i := range b.TestResults {
+		r := &b.TestResults[i]
+		inB[key{r.SuiteName, r.TestID}] = r
+	}
+
+	var diffs []ResultDifference
+	seen := make(map[key]bool, len(a.TestResults))
+	for i := range a.TestResults {
+		ra := &a.TestResults[i]
+		k := key{ra.SuiteName, ra.TestID}
+		seen[k] = true
+		rb, ok := inB[k]
+		if !ok {
+			diffs = append(diffs, ResultDifference{SuiteName: ra.SuiteName, TestID: ra.TestID, A: ra,
+				Description: fmt.Sprintf("%s vs not run", outcome(ra))})
+			continue
+		}
+		if description := compareOutcomes(ra, rb); description != "" {
+			diffs = append(diffs, ResultDifference{SuiteName: ra.SuiteName, TestID: ra.TestID, A: ra, B: rb,
+				Description: description})
+		}
+	}
+	for i := range b.TestResults {
+		rb := &b.TestResults[i]
+		if !seen[key{rb.SuiteName, rb.TestID}] {
+			diffs = append(diffs, ResultDifference{SuiteName: rb.SuiteName, TestID: rb.TestID, B: rb,
+				Description: fmt.Sprintf("not run vs %s", outcome(rb))})
+		}
+	}
+	return diffs
+}
+
+// compareOutcomes describes how the outcomes of a test in two runs differ, or returns
+// an empty string if they do not
+func compareOutcomes(a, b *SingleTestResult) string {
+	if a.Disabled != b.Disabled || a.Skipped != b.Skipped || a.Passed != b.Passed {
+		return fmt.Sprintf("%s vs %s", outcome(a), outcome(b))
+	}
+	if a.Passed || a.Disabled || a.Skipped {
+		return ""
+	}
+
+	if reasonA, reasonB := failureReason(a), failureReason(b); reasonA != reasonB {
+		return fmt.Sprintf("failed: %s vs failed: %s", reasonA, reasonB)
+	}
+	if codeA, codeB := errorCode(a), errorCode(b); codeA != codeB {
+		return fmt.Sprintf("failed: error %s vs failed: error %s", codeA, codeB)
+	}
+	return ""
+}
+
+// outcome describes the outcome of a test in a single word
+func outcome(r *SingleTestResult) string {
+	switch {
+	case r.Disabled:
+		return "disabled"
+	case r.Skipped:
+		return "skipped"
+	case r.Passed:
+		return "passed"
+	default:
+		return "failed"
+	}
+}
+
+// failureReason returns the validation error reason of a failed test, or describes
+// why it failed without one, e.g. because no response was received
+func failureReason(r *SingleTestResult) string {
+	switch {
+	case r.ValidationError != nil:
+		return r.ValidationError.Reason
+	case r.ReceivedResponse == nil:
+		return "no response"
+	default:
+		message, _, _ := strings.Cut(r.Message, "\n")
+		return message
+	}
+}
+
+// errorCode returns the error code of the response a test received as type.member,
+// "none" if the response has no error code
+func errorCode(r *SingleTestResult) string {
+	if r.ReceivedResponse == nil || r.ReceivedResponse.Error == nil || r.ReceivedResponse.Error.Code == nil {
+		return "none"
+	}
+	return r.ReceivedResponse.Error.Code.Type + "." + r.ReceivedResponse.Error.Code.Member
+}
@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"testing"
+)
+
+func TestCompareResults(t *testing.T) {
+	errorResponse := func(member string) *Response {
+		return &Response{Error: &Error{Code: &ErrorCode{Type: "btck_ScriptVerifyStatus", Member: member}}}
+	}
+	mismatch := &ValidationError{Reason: ReasonResultMismatch}
+	unexpectedError := &ValidationError{Reason: ReasonUnexpectedError}
+
+	a := TestResult{TestResults: []SingleTestResult{
+		{SuiteName: "S", TestID: "same-pass", Passed: true},
+		{SuiteName: "S", TestID: "same-fail", ReceivedResponse: errorResponse("x"), ValidationError: unexpectedError},
+		{SuiteName: "S", TestID: "pass-fail", Passed: true},
+		{SuiteName: "S", TestID: "reason", ReceivedResponse: &Response{}, ValidationError: mismatch},
+		{SuiteName: "S", TestID: "code", ReceivedResponse: errorResponse("x"), ValidationError: unexpectedError},
+		{SuiteName: "S", TestID: "disabled", Disabled: true},
+		{SuiteName: "S", TestID: "only-a", Passed: true},
+		{SuiteName: "T", TestID: "same-pass", Passed: true},
+	}}
+	b := TestResult{TestResults: []SingleTestResult{
+		{SuiteName: "S", TestID: "only-b"},
+		{SuiteName: "S", TestID: "same-pass", Passed: true},
+		{SuiteName: "S", TestID: "same-fail", ReceivedResponse: errorResponse("x"), ValidationError: unexpectedError},
+		{SuiteName: "S", TestID: "pass-fail", Message: "Failed to read response: handler timeout"},
+		{SuiteName: "S", TestID: "reason", ReceivedResponse: errorResponse("x"), ValidationError: unexpectedError},
+		{SuiteName: "S", TestID: "code", ReceivedResponse: errorResponse("y"), ValidationError: unexpectedError},
+		{SuiteName: "S", TestID: "disabled", Passed: true},
+		{SuiteName: "T", TestID: "same-pass"},
+	}}
+
+	want := []struct{ suite, id, description string }{
+		{"S", "pass-fail", "passed vs failed"},
+		{"S", "reason", "failed: ResultMismatch vs failed: UnexpectedError"},
+		{"S", "code", "failed: error btck_ScriptVerifyStatus.x vs failed: error btck_ScriptVerifyStatus.y"},
+		{"S", "disabled", "disabled vs passed"},
+		{"S", "only-a", "passed vs not run"},
+		{"T", "same-pass", "passed vs failed"},
+		{"S", "only-b", "not run vs failed"},
+	}
+
+	diffs := CompareResults(a, b)
+	if len(diffs) != len(want) {
+		t.Fatalf("expected %d differences, got %d: %+v", len(want), len(diffs), diffs)
+	}
+	for i, w := range want {
+		got := diffs[i]
+		if got.SuiteName != w.suite || got.TestID != w.id || got.Description != w.description {
+			t.Errorf("difference %d: expected %s::%s %q, got %s::%s %q",
+				i, w.suite, w.id, w.description, got.SuiteName, got.TestID, got.Description)
+		}
+	}
+	if diffs[4].B != nil || diffs[6].A != nil {
+		t.Errorf("expected no result for the run a test did not run in")
+	}
+
+	if diffs := CompareResults(a, a); len(diffs) != 0 {
+		t.Errorf("expected no differences comparing a run with itself, got %+v", diffs)
+	}
+}
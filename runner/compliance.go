@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// complianceCheck is a protocol micro-test run against a freshly spawned handler
+type complianceCheck struct {
+	name string
+	run  func(h *Handler) error
+	// closesHandler indicates the check closes the handler itself
+	closesHandler bool
+}
+
+var complianceChecks = []complianceCheck{
+	{name: "unknown method", run: checkUnknownMethod},
+	{name: "malformed request", run: checkMalformedRequest},
+	{name: "response id echo", run: checkResponseIDEcho},
+	{name: "__ping__", run: checkPing},
+	{name: "clean exit on stdin close", run: checkCleanExit, closesHandler: true},
+}
+
+// CheckProtocolCompliance spawns the handler described by cfg and runs a set of built-in
+// protocol checks independent of any test suite:
+//  1. an unknown method returns an error response
+//  2. a malformed JSON request returns an error response
+//  3. the response id echoes the request id
+//  4. the __ping__ method returns {"result": true}
+//  5. the handler exits cleanly when stdin is closed
+//
+// Each check runs against a fresh handler process. Returns one error per failed check.
+func CheckProtocolCompliance(ctx context.Context, cfg HandlerConfig) []error {
+	var errs []error
+	for _, check := range complianceChecks {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", check.name, err))
+			continue
+		}
+
+		h, err := NewHandler(&cfg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", check.name, err))
+			continue
+		}
+
+		if err := check.run(h); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", check.name, err))
+		}
+		if !check.closesHandler {
+			h.Close()
+		}
+	}
+	return errs
+}
+
+// exchange sends a raw request line and returns the raw response line
+func exchange(h *Handler, line string) ([]byte, error) {
+	if err := h.SendLine([]byte(line)); err != nil {
+		return nil, fmt.Errorf("failed to write request: %w", err)
+	}
+	resp, err := h.ReadLine()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp, nil
+}
+
+// expectErrorResponse checks that a raw response line is a valid error response
+func expectErrorResponse(line []byte) error {
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return fmt.Errorf("response is not valid JSON: %s", line)
+	}
+	if resp.Error == nil {
+		return fmt.Errorf("expected error response, got: %s", line)
+	}
+	if !resp.Result.IsNullOrOmitted() {
+		return fmt.Errorf("expected result to be null or omitted when error is present, got: %s", line)
+	}
+	return nil
+}
+
+func checkUnknownMethod(h *Handler) error {
+	line, err := exchange(h, `{"id":"__compliance_unknown__","method":"__unknown_method__"}`)
+	if err != nil {
+		return err
+	}
+	return expectErrorResponse(line)
+}
+
+func checkMalformedRequest(h *Handler) error {
+	line, err := exchange(h, `{"id":"__compliance_malformed__","method":`)
+	if err != nil {
+		return err
+	}
+	return expectErrorResponse(line)
+}
+
+func checkResponseIDEcho(h *Handler) error {
+	const id = "__compliance_echo__"
+	line, err := exchange(h, `{"id":"`+id+`","method":"__ping__"}`)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		ID *string `json:"id"`
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return fmt.Errorf("response is not valid JSON: %s", line)
+	}
+	if resp.ID == nil {
+		return fmt.Errorf("response does not contain an id: %s", line)
+	}
+	if *resp.ID != id {
+		return fmt.Errorf("expected response id %q, got %q", id, *resp.ID)
+	}
+	return nil
+}
+
+func checkPing(h *Handler) error {
+	line, err := exchange(h, `{"id":"__compliance_ping__","method":"__ping__"}`)
+	if err != nil {
+		return err
+	}
+
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return fmt.Errorf("response is not valid JSON: %s", line)
+	}
+	if resp.Error != nil || string(resp.Result) != "true" {
+		return fmt.Errorf(`expected {"result": true}, got: %s`, line)
+	}
+	return nil
+}
+
+func checkCleanExit(h *Handler) error {
+	h.Close()
+	return h.exitErr
+}
@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestCheckProtocolCompliance_Compliant tests that a protocol-compliant handler passes all checks
+func TestCheckProtocolCompliance_Compliant(t *testing.T) {
+	errs := CheckProtocolCompliance(context.Background(), HandlerConfig{
+		Path: os.Args[0],
+		Env:  []string{"TEST_AS_SUBPROCESS=1", "TEST_HELPER_NAME=" + helperNameCompliant},
+	})
+	for _, err := range errs {
+		t.Errorf("unexpected compliance failure: %v", err)
+	}
+}
+
+// TestCheckProtocolCompliance_Unresponsive tests that request/response checks fail for a
+// handler that never responds
+func TestCheckProtocolCompliance_Unresponsive(t *testing.T) {
+	errs := CheckProtocolCompliance(context.Background(), HandlerConfig{
+		Path:    os.Args[0],
+		Env:     []string{"TEST_AS_SUBPROCESS=1", "TEST_HELPER_NAME=" + helperNameUnresponsive},
+		Timeout: 100 * time.Millisecond,
+	})
+	// All four request/response checks time out. The clean exit check sends no request,
+	// so the helper exits normally when stdin closes.
+	if len(errs) != 4 {
+		t.Errorf("expected 4 compliance failures, got %d: %v", len(errs), errs)
+	}
+}
+
+// helperCompliant simulates a handler that implements the protocol-level requirements:
+// error responses for malformed requests and unknown methods, ID echo, and __ping__.
+func helperCompliant() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			fmt.Println(`{"error":{}}`)
+			continue
+		}
+		id, _ := json.Marshal(req.ID)
+		if req.Method == "__ping__" {
+			fmt.Printf(`{"id":%s,"result":true}`+"\n", id)
+			continue
+		}
+		fmt.Printf(`{"id":%s,"error":{}}`+"\n", id)
+	}
+}
@@ -3,6 +3,9 @@ package runner
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 )
 
 // statefulCreatorMethods contains methods that create stateful objects.
@@ -26,6 +29,13 @@ type DependencyTracker struct {
 	// refCreators maps reference names to the test index that created them
 	refCreators map[string]int
 
+	// executed maps the IDs of executed tests to their index, to resolve
+	// TestCase.DependsOn
+	executed map[string]int
+
+	// consumedRefs tracks the refs used in the params of a test
+	consumedRefs map[string]bool
+
 	// statefulRefs tracks refs created by stateful methods.
 	// Tests using these refs depend on mutable state.
 	statefulRefs map[string]bool
@@ -42,7 +52,9 @@ type DependencyTracker struct {
 func NewDependencyTracker() *DependencyTracker {
 	return &DependencyTracker{
 		refCreators:       make(map[string]int),
+		executed:          make(map[string]int),
 		statefulRefs:      make(map[string]bool),
+		consumedRefs:      make(map[string]bool),
 		depChains:         make(map[int][]int),
 		stateDependencies: []int{},
 	}
@@ -51,11 +63,15 @@ func NewDependencyTracker() *DependencyTracker {
 // BuildDependenciesForTest analyzes a test's parameters to build its complete transitive
 // dependency chain. When a test uses refs created by earlier tests, this records all direct
 // dependencies (tests that created those refs) and indirect dependencies (their dependencies).
-// Must be called after all previous tests have been processed.
-func (dt *DependencyTracker) BuildDependenciesForTest(testIndex int, test *TestCase) {
+// Tests listed in the test's DependsOn are direct dependencies as well, unless they have
+// not been executed, e.g. because they are disabled.
+// Must be called after all previous tests have been processed. Returns an error if the
+// test uses a ref that no executed test created.
+func (dt *DependencyTracker) BuildDependenciesForTest(testIndex int, test *TestCase) error {
 	// Build dependency chain for current test based on refs it uses
 	var parentChains [][]int
 	for _, ref := range extractRefsFromParams(test.Request.Params) {
+		dt.consumedRefs[ref] = true
 		if creatorIdx, exists := dt.refCreators[ref]; exists {
 			// Add the creator as a direct dependency
 			parentChains = append(parentChains, []int{creatorIdx})
@@ -64,17 +80,25 @@ func (dt *DependencyTracker) BuildDependenciesForTest(testIndex int, test *TestC
 				parentChains = append(parentChains, chain)
 			}
 		} else {
-			panic(fmt.Sprintf("test %d (%s) uses undefined reference %s - no prior test created this ref",
-				testIndex, test.Request.ID, ref))
+			return fmt.Errorf("test %s uses undefined reference %s: no prior test created this ref",
+				test.Request.ID, ref)
+		}
+	}
+	for _, id := range test.DependsOn {
+		if depIdx, exists := dt.executed[id]; exists {
+			parentChains = append(parentChains, []int{depIdx}, dt.depChains[depIdx])
 		}
 	}
 	dt.depChains[testIndex] = mergeSortedUnique(parentChains...)
+	return nil
 }
 
 // OnTestExecuted is called after a test executes successfully. It tracks the ref
 // created by the test, marks it as stateful if needed, and updates state dependencies
 // for state-mutating methods.
 func (dt *DependencyTracker) OnTestExecuted(testIndex int, test *TestCase) {
+	dt.executed[test.Request.ID] = testIndex
+
 	// Track ref creation using the request's ref field
 	if test.Request.Ref != "" {
 		dt.refCreators[test.Request.Ref] = testIndex
@@ -92,6 +116,20 @@ func (dt *DependencyTracker) OnTestExecuted(testIndex int, test *TestCase) {
 	}
 }
 
+// FindOrphanedRefs returns, in sorted order, the refs created by executed tests that
+// no test processed by BuildDependenciesForTest uses. These usually point to a suite
+// authoring mistake: the result of a test is captured, but never used downstream.
+func (dt *DependencyTracker) FindOrphanedRefs() []string {
+	var orphaned []string
+	for ref := range dt.refCreators {
+		if !dt.consumedRefs[ref] {
+			orphaned = append(orphaned, ref)
+		}
+	}
+	slices.Sort(orphaned)
+	return orphaned
+}
+
 // BuildRequestChain builds the complete dependency chain for a test
 func (dt *DependencyTracker) BuildRequestChain(testIndex int, allTests []TestCase) []int {
 	refDepChain := dt.depChains[testIndex]
@@ -124,8 +162,215 @@ func (dt *DependencyTracker) testUsesStatefulRefs(testIndex int, allTests []Test
 	return false
 }
 
+// BuildExecutionOrder returns the indices of tests in an order where every test comes
+// after the tests that create the refs it uses. This is GetExecutionOrder with the
+// layers concatenated.
+func (dt *DependencyTracker) BuildExecutionOrder(tests []TestCase) ([]int, error) {
+	layers, err := dt.GetExecutionOrder(tests)
+	if err != nil {
+		return nil, err
+	}
+	order := make([]int, 0, len(tests))
+	for _, layer := range layers {
+		order = append(order, layer...)
+	}
+	return order, nil
+}
+
+// GetExecutionOrder sorts tests topologically by the refs they create and use, and
+// returns the result as layers of test indices. The tests within a layer do not depend
+// on each other, and all of their dependencies are in earlier layers, so the tests of a
+// layer could run concurrently. Indices within a layer are in file order. Returns an
+// error if a test uses a ref that no test in the suite creates, or if the dependencies
+// form a cycle.
+func (dt *DependencyTracker) GetExecutionOrder(tests []TestCase) ([][]int, error) {
+	deps, err := directDependencies(tests, false)
+	if err != nil {
+		return nil, err
+	}
+
+	dependents := make(map[int][]int)
+	pending := make([]int, len(tests))
+	var layer []int
+	for i, testDeps := range deps {
+		pending[i] = len(testDeps)
+		for _, dep := range testDeps {
+			dependents[dep] = append(dependents[dep], i)
+		}
+		if pending[i] == 0 {
+			layer = append(layer, i)
+		}
+	}
+
+	var layers [][]int
+	ordered := 0
+	for len(layer) > 0 {
+		layers = append(layers, layer)
+		ordered += len(layer)
+
+		var next []int
+		for _, i := range layer {
+			for _, dependent := range dependents[i] {
+				pending[dependent]--
+				if pending[dependent] == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+		slices.Sort(next)
+		layer = next
+	}
+
+	if ordered < len(tests) {
+		return nil, cycleError(tests, findCycle(deps))
+	}
+	return layers, nil
+}
+
+// DetectCycles reports whether the tests depend on each other in a cycle, through the
+// refs they create and use or their DependsOn, which no execution order can satisfy.
+// Returns an error naming the tests of the first cycle found in file order, e.g.
+// "dependency cycle: a -> b -> a". Refs that no test creates and unknown DependsOn
+// IDs are ignored here; they are reported when the tests are ordered or loaded.
+func (dt *DependencyTracker) DetectCycles(tests []TestCase) error {
+	deps, _ := directDependencies(tests, true)
+	if cycle := findCycle(deps); cycle != nil {
+		return cycleError(tests, cycle)
+	}
+	return nil
+}
+
+// findCycle returns the indices of the first cycle in the dependency graph deps, found
+// by a depth-first search in index order, with the first test repeated at the end. Returns
+// nil if the graph has no cycle.
+func findCycle(deps [][]int) []int {
+	// path holds the tests being visited. A dependency on a test in path closes a cycle.
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(deps))
+	var path []int
+	var visit func(i int) []int
+	visit = func(i int) []int {
+		state[i] = visiting
+		path = append(path, i)
+		for _, dep := range deps[i] {
+			switch state[dep] {
+			case visiting:
+				start := slices.Index(path, dep)
+				return append(slices.Clone(path[start:]), dep)
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		return nil
+	}
+
+	for i := range deps {
+		if state[i] != unvisited {
+			continue
+		}
+		if cycle := visit(i); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// cycleError returns the error describing a cycle returned by findCycle
+func cycleError(tests []TestCase, cycle []int) error {
+	names := make([]string, len(cycle))
+	for i, idx := range cycle {
+		names[i] = tests[idx].Request.ID
+	}
+	return fmt.Errorf("dependency cycle: %s", strings.Join(names, " -> "))
+}
+
+// directDependencies returns, for every test, the indices of the tests that create the
+// refs it uses and of the tests it lists in DependsOn, in ascending order. Returns an
+// error if a ref or DependsOn ID cannot be resolved or a test depends on itself, unless
+// lenient is set; then unresolved refs and IDs are skipped and a test depending on
+// itself is listed as its own dependency.
+func directDependencies(tests []TestCase, lenient bool) ([][]int, error) {
+	creators := make(map[string]int)
+	ids := make(map[string]int)
+	for i, test := range tests {
+		if _, exists := ids[test.Request.ID]; !exists {
+			ids[test.Request.ID] = i
+		}
+		if test.Request.Ref == "" {
+			continue
+		}
+		if _, exists := creators[test.Request.Ref]; !exists {
+			creators[test.Request.Ref] = i
+		}
+	}
+
+	deps := make([][]int, len(tests))
+	for i, test := range tests {
+		for _, ref := range extractRefsFromParams(test.Request.Params) {
+			creatorIdx, exists := creators[ref]
+			switch {
+			case !exists && lenient:
+				continue
+			case !exists:
+				return nil, fmt.Errorf("test %s uses reference %s, which no test creates", test.Request.ID, ref)
+			case creatorIdx == i && !lenient:
+				return nil, fmt.Errorf("test %s uses the reference %s it creates", test.Request.ID, ref)
+			}
+			deps[i] = append(deps[i], creatorIdx)
+		}
+		for _, id := range test.DependsOn {
+			depIdx, exists := ids[id]
+			switch {
+			case !exists && lenient:
+				continue
+			case !exists:
+				return nil, fmt.Errorf("test %s depends on test %s, which is not in the suite", test.Request.ID, id)
+			case depIdx == i && !lenient:
+				return nil, fmt.Errorf("test %s depends on itself", test.Request.ID)
+			}
+			deps[i] = append(deps[i], depIdx)
+		}
+		// Params are unordered, so sort to report the same cycle every time
+		slices.Sort(deps[i])
+	}
+	return deps, nil
+}
+
+// validateDependsOn checks that every test listed in a DependsOn of the suite exists
+// and, unless the suite is dependency-ordered, comes before the test depending on it
+func validateDependsOn(suite *TestSuite) error {
+	ids := make(map[string]int, len(suite.Tests))
+	for i, test := range suite.Tests {
+		if _, exists := ids[test.Request.ID]; !exists {
+			ids[test.Request.ID] = i
+		}
+	}
+	for i, test := range suite.Tests {
+		for _, id := range test.DependsOn {
+			depIdx, exists := ids[id]
+			switch {
+			case !exists:
+				return fmt.Errorf("test %s: depends_on names unknown test %s", test.Request.ID, id)
+			case depIdx >= i && suite.Model() != ExecutionDependencyOrdered:
+				return fmt.Errorf("test %s: depends_on names test %s, which does not run before it", test.Request.ID, id)
+			}
+		}
+	}
+	return nil
+}
+
 // extractRefsFromParams extracts all reference names from params JSON.
-// Searches for ref objects with structure {"ref": "..."} at the first level of params.
+// Searches for ref objects with structure {"ref": "..."} at any depth of params,
+// including within nested objects and arrays. Object keys are visited in sorted order,
+// so the refs are returned in the same order every time.
 func extractRefsFromParams(params json.RawMessage) []string {
 	var refs []string
 
@@ -134,9 +379,29 @@ func extractRefsFromParams(params json.RawMessage) []string {
 		return refs
 	}
 
-	for _, value := range paramsMap {
-		if ref, ok := ParseRefObject(value); ok {
-			refs = append(refs, ref)
+	for _, key := range slices.Sorted(maps.Keys(paramsMap)) {
+		refs = appendRefs(refs, paramsMap[key])
+	}
+	return refs
+}
+
+// appendRefs appends the refs of value to refs: its own if it is a ref object, and
+// otherwise those of the values nested in it
+func appendRefs(refs []string, value json.RawMessage) []string {
+	if ref, ok := ParseRefObject(value); ok {
+		return append(refs, ref)
+	}
+
+	var object map[string]json.RawMessage
+	var array []json.RawMessage
+	switch {
+	case json.Unmarshal(value, &object) == nil:
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			refs = appendRefs(refs, object[key])
+		}
+	case json.Unmarshal(value, &array) == nil:
+		for _, elem := range array {
+			refs = appendRefs(refs, elem)
 		}
 	}
 	return refs
@@ -56,7 +56,9 @@ func TestDependencyTracker_BuildDependencyChains(t *testing.T) {
 
 	for i := range testCases {
 		test := &testCases[i]
-		tracker.BuildDependenciesForTest(i, test)
+		if err := tracker.BuildDependenciesForTest(i, test); err != nil {
+			t.Fatalf("BuildDependenciesForTest() error: %v", err)
+		}
 		tracker.OnTestExecuted(i, test)
 	}
 
@@ -138,7 +140,9 @@ func TestDependencyTracker_StatefulRefs(t *testing.T) {
 
 	for i := range testCases {
 		test := &testCases[i]
-		tracker.BuildDependenciesForTest(i, test)
+		if err := tracker.BuildDependenciesForTest(i, test); err != nil {
+			t.Fatalf("BuildDependenciesForTest() error: %v", err)
+		}
 		tracker.OnTestExecuted(i, test)
 	}
 
@@ -211,7 +215,9 @@ func TestDependencyTracker_StateMutations(t *testing.T) {
 
 	for i := range testCases {
 		test := &testCases[i]
-		tracker.BuildDependenciesForTest(i, test)
+		if err := tracker.BuildDependenciesForTest(i, test); err != nil {
+			t.Fatalf("BuildDependenciesForTest() error: %v", err)
+		}
 		tracker.OnTestExecuted(i, test)
 	}
 
@@ -288,7 +294,9 @@ func TestDependencyTracker_BuildRequestChain(t *testing.T) {
 
 	for i := range testCases {
 		test := &testCases[i]
-		tracker.BuildDependenciesForTest(i, test)
+		if err := tracker.BuildDependenciesForTest(i, test); err != nil {
+			t.Fatalf("BuildDependenciesForTest() error: %v", err)
+		}
 		tracker.OnTestExecuted(i, test)
 	}
 
@@ -318,3 +326,244 @@ func TestDependencyTracker_BuildRequestChain(t *testing.T) {
 		})
 	}
 }
+
+func TestDependencyTracker_GetExecutionOrder(t *testing.T) {
+	test := func(id, ref string, uses ...string) TestCase {
+		params := map[string]any{}
+		for i, use := range uses {
+			params[string(rune('a'+i))] = map[string]string{"ref": use}
+		}
+		data, _ := json.Marshal(params)
+		return TestCase{Request: Request{ID: id, Method: "m", Params: data, Ref: ref}}
+	}
+	dependsOn := func(tc TestCase, ids ...string) TestCase {
+		tc.DependsOn = ids
+		return tc
+	}
+
+	tests := []struct {
+		name       string
+		tests      []TestCase
+		wantLayers [][]int
+		wantErr    bool
+	}{
+		{
+			name:       "independent",
+			tests:      []TestCase{test("0", ""), test("1", "$a"), test("2", "")},
+			wantLayers: [][]int{{0, 1, 2}},
+		},
+		{
+			name:       "user before creator",
+			tests:      []TestCase{test("0", "", "$b"), test("1", "$a"), test("2", "$b", "$a")},
+			wantLayers: [][]int{{1}, {2}, {0}},
+		},
+		{
+			name: "diamond",
+			tests: []TestCase{
+				test("0", "", "$b", "$c"),
+				test("1", "$c", "$a"),
+				test("2", "$b", "$a"),
+				test("3", "$a"),
+				test("4", ""),
+			},
+			wantLayers: [][]int{{3, 4}, {1, 2}, {0}},
+		},
+		{
+			name:       "depends_on",
+			tests:      []TestCase{dependsOn(test("0", ""), "2"), test("1", "$a"), dependsOn(test("2", "", "$a"), "3"), test("3", "")},
+			wantLayers: [][]int{{1, 3}, {2}, {0}},
+		},
+		{
+			name:    "unknown depends_on",
+			tests:   []TestCase{dependsOn(test("0", ""), "missing")},
+			wantErr: true,
+		},
+		{
+			name:    "depends on itself",
+			tests:   []TestCase{dependsOn(test("0", ""), "0")},
+			wantErr: true,
+		},
+		{
+			name:    "undefined ref",
+			tests:   []TestCase{test("0", "", "$missing")},
+			wantErr: true,
+		},
+		{
+			name:    "cycle",
+			tests:   []TestCase{test("0", "$a", "$b"), test("1", "$b", "$a"), test("2", "")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dt := NewDependencyTracker()
+			layers, err := dt.GetExecutionOrder(tt.tests)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetExecutionOrder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.EqualFunc(layers, tt.wantLayers, slices.Equal[[]int]) {
+				t.Errorf("GetExecutionOrder() = %v, want %v", layers, tt.wantLayers)
+			}
+
+			// BuildExecutionOrder is the concatenation of the layers
+			order, err := dt.BuildExecutionOrder(tt.tests)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildExecutionOrder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if want := slices.Concat(tt.wantLayers...); !slices.Equal(order, want) {
+				t.Errorf("BuildExecutionOrder() = %v, want %v", order, want)
+			}
+		})
+	}
+}
+
+func TestDependencyTracker_DependsOn(t *testing.T) {
+	tests := []TestCase{
+		{Request: Request{ID: "setup", Method: "setup"}},
+		{Request: Request{ID: "create", Method: "create", Ref: "$a"}, DependsOn: []string{"setup"}},
+		{Request: Request{ID: "use", Method: "use", Params: json.RawMessage(`{"a": {"ref": "$a"}}`)}},
+		{Request: Request{ID: "verify", Method: "verify"}, DependsOn: []string{"use", "disabled"}},
+	}
+
+	tracker := NewDependencyTracker()
+	for i := range tests {
+		if err := tracker.BuildDependenciesForTest(i, &tests[i]); err != nil {
+			t.Fatalf("BuildDependenciesForTest() error: %v", err)
+		}
+		tracker.OnTestExecuted(i, &tests[i])
+	}
+
+	// Tests that did not run, like "disabled", are left out of the chain
+	for i, want := range [][]int{nil, {0}, {0, 1}, {0, 1, 2}} {
+		if got := tracker.BuildRequestChain(i, tests); !slices.Equal(got, want) {
+			t.Errorf("request chain of %s = %v, want %v", tests[i].Request.ID, got, want)
+		}
+	}
+}
+
+func TestValidateDependsOn(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   ExecutionModel
+		deps    [][]string
+		wantErr bool
+	}{
+		{name: "earlier test", deps: [][]string{nil, {"0"}}},
+		{name: "later test", deps: [][]string{{"1"}, nil}, wantErr: true},
+		{name: "later test in dependency-ordered suite", model: ExecutionDependencyOrdered, deps: [][]string{{"1"}, nil}},
+		{name: "unknown test", model: ExecutionDependencyOrdered, deps: [][]string{{"missing"}, nil}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suite := TestSuite{ExecutionModel: tt.model}
+			for i, deps := range tt.deps {
+				suite.Tests = append(suite.Tests, TestCase{Request: Request{ID: string(rune('0' + i))}, DependsOn: deps})
+			}
+			if err := validateDependsOn(&suite); (err != nil) != tt.wantErr {
+				t.Errorf("validateDependsOn() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDependencyTracker_DetectCycles(t *testing.T) {
+	test := func(id, ref string, uses ...string) TestCase {
+		params := map[string]any{}
+		for i, use := range uses {
+			params[string(rune('a'+i))] = map[string]string{"ref": use}
+		}
+		data, _ := json.Marshal(params)
+		return TestCase{Request: Request{ID: id, Method: "m", Params: data, Ref: ref}}
+	}
+
+	tests := []struct {
+		name  string
+		tests []TestCase
+		want  string
+	}{
+		{
+			name:  "no cycle",
+			tests: []TestCase{test("a", "$a"), test("b", "$b", "$a"), test("c", "", "$a", "$b", "$undefined")},
+		},
+		{
+			name:  "ref cycle",
+			tests: []TestCase{test("x", ""), test("a", "$a", "$c"), test("b", "$b", "$a"), test("c", "$c", "$b")},
+			want:  "dependency cycle: a -> c -> b -> a",
+		},
+		{
+			name:  "depends_on cycle",
+			tests: []TestCase{test("a", "$a"), {Request: Request{ID: "b", Method: "m"}, DependsOn: []string{"a", "c"}}, {Request: Request{ID: "c", Method: "m"}, DependsOn: []string{"b"}}},
+			want:  "dependency cycle: b -> c -> b",
+		},
+		{
+			name:  "uses own ref",
+			tests: []TestCase{test("a", "$a", "$a")},
+			want:  "dependency cycle: a -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewDependencyTracker().DetectCycles(tt.tests)
+			if (err == nil) != (tt.want == "") || (err != nil && err.Error() != tt.want) {
+				t.Errorf("DetectCycles() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTestSuite_OrphanedRefs(t *testing.T) {
+	suite := TestSuite{
+		Stateful: true,
+		Tests: []TestCase{
+			{Request: Request{ID: "1", Method: "create", Ref: "$used"}},
+			{Request: Request{ID: "2", Method: "create", Ref: "$unused_b"}},
+			{Request: Request{ID: "3", Method: "create", Ref: "$unused_a"}},
+			{Request: Request{ID: "4", Method: "use", Params: json.RawMessage(`{"x": {"ref": "$used"}}`)}},
+		},
+	}
+
+	orphaned, err := suite.OrphanedRefs()
+	if err != nil {
+		t.Fatalf("OrphanedRefs() error: %v", err)
+	}
+	if want := []string{"$unused_a", "$unused_b"}; !slices.Equal(orphaned, want) {
+		t.Errorf("OrphanedRefs() = %v, want %v", orphaned, want)
+	}
+
+	suite.Tests[3].Request.Params = json.RawMessage(`{"x": {"ref": "$undefined"}}`)
+	if _, err := suite.OrphanedRefs(); err == nil {
+		t.Errorf("expected error for a ref that no test creates")
+	}
+}
+
+func TestExtractRefsFromParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		want   []string
+	}{
+		{name: "top level", params: `{"b": {"ref": "$b"}, "a": {"ref": "$a"}, "n": 1}`, want: []string{"$a", "$b"}},
+		{name: "nested object", params: `{"options": {"context": {"ref": "$context"}, "flags": 3}}`, want: []string{"$context"}},
+		{name: "array", params: `{"inputs": [{"ref": "$tx1"}, {"spent": {"ref": "$out"}}, "literal", [{"ref": "$tx2"}]]}`, want: []string{"$tx1", "$out", "$tx2"}},
+		{name: "no refs", params: `{"ref": "$not_a_ref_object", "values": [1, null, "$x"]}`},
+		{name: "params not an object", params: `[{"ref": "$a"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractRefsFromParams(json.RawMessage(tt.params)); !slices.Equal(got, tt.want) {
+				t.Errorf("extractRefsFromParams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDependencyTracker_UndefinedRef(t *testing.T) {
+	test := TestCase{Request: Request{ID: "use", Method: "m", Params: json.RawMessage(`{"x": {"ref": "$missing"}}`)}}
+	if err := NewDependencyTracker().BuildDependenciesForTest(0, &test); err == nil {
+		t.Errorf("expected error for a ref that no executed test created")
+	}
+}
@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// DiffJSON compares two JSON values recursively and describes how actual differs from
+// expected, one line per difference in the style of a unified diff: values only in
+// expected are prefixed with "- ", values only in actual with "+ ", and changed values
+// get both lines. Each line names the path of the value in jq notation (e.g.
+// ".blocks[1].hash"), except for the top-level value. Object keys are compared in
+// sorted order and array elements by position. Returns an empty string if the values
+// are equal, and both values in full if either is not valid JSON.
+func DiffJSON(expected, actual []byte) string {
+	var exp, act interface{}
+	if json.Unmarshal(expected, &exp) != nil || json.Unmarshal(actual, &act) != nil {
+		if string(expected) == string(actual) {
+			return ""
+		}
+		return fmt.Sprintf("- %s\n+ %s", expected, actual)
+	}
+
+	var lines []string
+	diffJSONValues("", exp, act, &lines)
+	return strings.Join(lines, "\n")
+}
+
+// diffJSONValues appends the differences between the decoded values exp and act at path
+// to lines
+func diffJSONValues(path string, exp, act interface{}, lines *[]string) {
+	switch e := exp.(type) {
+	case map[string]interface{}:
+		if a, ok := act.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(e)+len(a))
+			for key := range e {
+				keys = append(keys, key)
+			}
+			for key := range a {
+				if _, ok := e[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			slices.Sort(keys)
+			for _, key := range keys {
+				expValue, inExp := e[key]
+				actValue, inAct := a[key]
+				keyPath := path + "." + jsonPathKey(key)
+				switch {
+				case !inAct:
+					*lines = append(*lines, diffLine("-", keyPath, expValue))
+				case !inExp:
+					*lines = append(*lines, diffLine("+", keyPath, actValue))
+				default:
+					diffJSONValues(keyPath, expValue, actValue, lines)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if a, ok := act.([]interface{}); ok {
+			if path == "" {
+				path = "."
+			}
+			for i := 0; i < max(len(e), len(a)); i++ {
+				elemPath := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(a):
+					*lines = append(*lines, diffLine("-", elemPath, e[i]))
+				case i >= len(e):
+					*lines = append(*lines, diffLine("+", elemPath, a[i]))
+				default:
+					diffJSONValues(elemPath, e[i], a[i], lines)
+				}
+			}
+			return
+		}
+	}
+
+	// Scalars, or values of different types
+	if !reflect.DeepEqual(exp, act) {
+		*lines = append(*lines, diffLine("-", path, exp), diffLine("+", path, act))
+	}
+}
+
+// diffLine formats a single line of DiffJSON output
+func diffLine(prefix, path string, value interface{}) string {
+	data, _ := json.Marshal(value)
+	if path == "" {
+		return fmt.Sprintf("%s %s", prefix, data)
+	}
+	return fmt.Sprintf("%s %s: %s", prefix, path, data)
+}
+
+// jsonPathKey returns key as it appears in a jq path: unchanged if it is a plain
+// identifier, and quoted otherwise
+func jsonPathKey(key string) string {
+	for i, r := range key {
+		if r != '_' && !('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') && !(i > 0 && '0' <= r && r <= '9') {
+			return fmt.Sprintf("%q", key)
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDiffJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		want     string
+	}{
+		{
+			name:     "equal after normalization",
+			expected: `{"a": 1.0, "b": [true, null]}`,
+			actual:   `{"b":[true,null],"a":1}`,
+			want:     "",
+		},
+		{
+			name:     "top-level scalar",
+			expected: `1`,
+			actual:   `"1"`,
+			want:     "- 1\n+ \"1\"",
+		},
+		{
+			name:     "changed, removed, and added keys",
+			expected: `{"height": 1, "hash": "aa", "nested": {"x": 1}}`,
+			actual:   `{"height": 2, "nested": {"x": 1, "y": [1]}, "valid": true}`,
+			want: "- .hash: \"aa\"\n" +
+				"- .height: 1\n" +
+				"+ .height: 2\n" +
+				"+ .nested.y: [1]\n" +
+				"+ .valid: true",
+		},
+		{
+			name:     "array elements",
+			expected: `[{"id": 1}, {"id": 2}, 3]`,
+			actual:   `[{"id": 1}, {"id": 5}]`,
+			want:     "- .[1].id: 2\n+ .[1].id: 5\n- .[2]: 3",
+		},
+		{
+			name:     "type change",
+			expected: `{"data": {"x": 1}}`,
+			actual:   `{"data": [1]}`,
+			want:     "- .data: {\"x\":1}\n+ .data: [1]",
+		},
+		{
+			name:     "quoted keys",
+			expected: `{"chain type": 1}`,
+			actual:   `{"chain type": 2}`,
+			want:     "- .\"chain type\": 1\n+ .\"chain type\": 2",
+		},
+		{
+			name:     "invalid JSON",
+			expected: `1`,
+			actual:   `abc`,
+			want:     "- 1\n+ abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffJSON([]byte(tt.expected), []byte(tt.actual)); got != tt.want {
+				t.Errorf("DiffJSON() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunTest_ResultMismatchDiff(t *testing.T) {
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		return Response{Result: Result(`{"height": 2, "hash": "aa"}`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	test := TestCase{
+		Request:          Request{ID: "1", Method: "m"},
+		ExpectedResponse: Response{Result: Result(`{"height": 1, "hash": "aa"}`)},
+	}
+	result := tr.runTest(context.Background(), &test)
+	if result.Passed {
+		t.Fatalf("expected result mismatch")
+	}
+	if !strings.Contains(result.Message, "- .height: 1\n      + .height: 2") {
+		t.Errorf("expected message to contain the diff, got %q", result.Message)
+	}
+	if strings.Contains(result.Message, "hash") {
+		t.Errorf("expected message to omit equal fields, got %q", result.Message)
+	}
+}
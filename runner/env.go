@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// envRequestID is the request ID used when querying the handler environment
+const envRequestID = "__env__"
+
+// envCheckTestID is the test ID under which a failed environment assertion is reported
+const envCheckTestID = "__env__"
+
+// getEnvParams are the parameters of the __get_env__ protocol method
+type getEnvParams struct {
+	Keys []string `json:"keys"`
+}
+
+// checkHandlerEnv asks the handler for the values of the expected environment variables
+// via __get_env__ and returns an error describing every mismatch. Handlers that do not
+// implement __get_env__ are not checked; a warning is logged instead.
+func (tr *TestRunner) checkHandlerEnv(expected map[string]string) error {
+	keys := slices.Sorted(maps.Keys(expected))
+	params, err := json.Marshal(getEnvParams{Keys: keys})
+	if err != nil {
+		return fmt.Errorf("failed to marshal __get_env__ params: %w", err)
+	}
+
+	if err := tr.SendRequest(Request{ID: envRequestID, Method: "__get_env__", Params: params}); err != nil {
+		return fmt.Errorf("failed to query handler environment: %w", err)
+	}
+	resp, err := tr.ReadResponse()
+	if err != nil {
+		return fmt.Errorf("failed to read __get_env__ response: %w", err)
+	}
+	if resp.Error != nil {
+		tr.log().Warn("handler does not implement __get_env__, skipping environment check")
+		return nil
+	}
+
+	var actual map[string]string
+	if err := json.Unmarshal(resp.Result, &actual); err != nil {
+		return fmt.Errorf("unexpected __get_env__ result: %s", string(resp.Result))
+	}
+
+	var mismatches []string
+	for _, key := range keys {
+		if actual[key] != expected[key] {
+			mismatches = append(mismatches, fmt.Sprintf("%s=%q (expected %q)", key, actual[key], expected[key]))
+		}
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("handler environment mismatch: %s", strings.Join(mismatches, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRunTestSuite_AssertHandlerEnv(t *testing.T) {
+	env := map[string]string{"NETWORK": "regtest", "DATADIR": "/tmp/kernel"}
+
+	newRunner := func(t *testing.T, supportsEnv bool) *TestRunner {
+		t.Helper()
+		tr, err := NewTestRunnerInProcess(func(req Request) Response {
+			if req.Method != "__get_env__" {
+				return Response{Result: Result(`true`)}
+			}
+			if !supportsEnv {
+				return Response{Error: &Error{Code: &ErrorCode{Type: "Handler", Member: "UNKNOWN_METHOD"}}}
+			}
+			var params getEnvParams
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				t.Fatalf("invalid __get_env__ params: %v", err)
+			}
+			values := make(map[string]string)
+			for _, key := range params.Keys {
+				values[key] = env[key]
+			}
+			result, _ := json.Marshal(values)
+			return Response{Result: result}
+		}, 0)
+		if err != nil {
+			t.Fatalf("failed to create in-process runner: %v", err)
+		}
+		t.Cleanup(tr.CloseHandler)
+		return tr
+	}
+
+	newSuite := func(expected map[string]string) TestSuite {
+		return TestSuite{
+			Name:             "Env",
+			AssertHandlerEnv: expected,
+			Tests: []TestCase{
+				{Request: Request{ID: "1", Method: "m"}, Description: "first test", ExpectedResponse: Response{Result: Result(`true`)}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		supportsEnv bool
+		expected    map[string]string
+		wantFailed  int
+		wantMessage string
+	}{
+		{
+			name:        "matching environment",
+			supportsEnv: true,
+			expected:    map[string]string{"NETWORK": "regtest", "DATADIR": "/tmp/kernel"},
+		},
+		{
+			name:        "mismatched environment",
+			supportsEnv: true,
+			expected:    map[string]string{"NETWORK": "mainnet", "MISSING": "x"},
+			wantFailed:  1,
+			wantMessage: `MISSING="" (expected "x"), NETWORK="regtest" (expected "mainnet")`,
+		},
+		{
+			name:        "handler without __get_env__",
+			supportsEnv: false,
+			expected:    map[string]string{"NETWORK": "mainnet"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newRunner(t, tt.supportsEnv)
+			result := tr.RunTestSuite(context.Background(), newSuite(tt.expected), RunOptions{})
+
+			if result.FailedTests != tt.wantFailed {
+				t.Fatalf("expected %d failed tests, got %d: %+v", tt.wantFailed, result.FailedTests, result.TestResults)
+			}
+			if result.PassedTests != 1 {
+				t.Errorf("expected the suite's test to pass, got %d passed", result.PassedTests)
+			}
+			if tt.wantFailed > 0 {
+				got := result.TestResults[0]
+				if got.TestID != envCheckTestID || !strings.Contains(got.Message, tt.wantMessage) {
+					t.Errorf("unexpected env check result: %+v", got)
+				}
+			}
+			// The env check result comes first; the test's own result keeps its description
+			if last := result.TestResults[len(result.TestResults)-1]; last.TestID != "1" || last.Description != "first test" {
+				t.Errorf("expected the result of test 1 with its description, got %+v", last)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package runner
+
+import (
+	"fmt"
+	"path"
+	"slices"
+)
+
+// FilterTests removes the tests of the suite whose request ID does not match pattern,
+// using path.Match syntax, and returns the number of tests removed. In stateful suites,
+// the tests that a matching test depends on are kept as well, so that the filtered
+// suite stays self-consistent. These are the tests of its request chain (see
+// DependencyTracker.BuildRequestChain).
+func (s *TestSuite) FilterTests(pattern string) (removed int, err error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	return s.keepMatching(func(test *TestCase) bool {
+		matched, _ := path.Match(pattern, test.Request.ID)
+		return matched
+	})
+}
+
+// FilterTags removes the tests of the suite that have none of tags, counting the tags
+// of the suite as tags of each of its tests, and returns the number of tests removed.
+// Like with FilterTests, stateful suites keep the tests that a remaining test depends
+// on.
+func (s *TestSuite) FilterTags(tags []string) (removed int, err error) {
+	return s.keepMatching(func(test *TestCase) bool {
+		for _, tag := range tags {
+			if slices.Contains(test.Tags, tag) || slices.Contains(s.Tags, tag) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// FocusTests removes the tests of the suite that are not marked Only if any test is,
+// and returns the number of tests removed. Like with FilterTests, stateful suites keep
+// the tests that a remaining test depends on.
+func (s *TestSuite) FocusTests() (removed int, err error) {
+	if !slices.ContainsFunc(s.Tests, func(test TestCase) bool { return test.Only }) {
+		return 0, nil
+	}
+	return s.keepMatching(func(test *TestCase) bool {
+		return test.Only
+	})
+}
+
+// keepMatching removes the tests of the suite for which match returns false, except
+// for those a matching test depends on in stateful suites, and returns the number of
+// tests removed
+func (s *TestSuite) keepMatching(match func(test *TestCase) bool) (removed int, err error) {
+	order, chains, err := s.requestChains()
+	if err != nil {
+		return 0, err
+	}
+
+	keep := make([]bool, len(s.Tests))
+	for _, i := range order {
+		if match(&s.Tests[i]) {
+			keep[i] = true
+			for _, dep := range chains[i] {
+				keep[dep] = true
+			}
+		}
+	}
+
+	removed = len(s.Tests) - s.keepTests(keep)
+	return removed, nil
+}
+
+// ExcludeTests removes the tests of the suite whose request ID matches pattern, using
+// path.Match syntax, and returns the IDs of the removed tests. In stateful suites, the
+// tests that depend on a removed test are removed as well, as they could not succeed
+// without it. These are the tests whose request chain (see
+// DependencyTracker.BuildRequestChain) includes a removed test.
+func (s *TestSuite) ExcludeTests(pattern string) (excluded []string, err error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	order, chains, err := s.requestChains()
+	if err != nil {
+		return nil, err
+	}
+
+	keep := make([]bool, len(s.Tests))
+	for _, i := range order {
+		matched, _ := path.Match(pattern, s.Tests[i].Request.ID)
+		keep[i] = !matched
+		for _, dep := range chains[i] {
+			if !keep[dep] {
+				keep[i] = false
+			}
+		}
+		if !keep[i] {
+			excluded = append(excluded, s.Tests[i].Request.ID)
+		}
+	}
+
+	s.keepTests(keep)
+	return excluded, nil
+}
+
+// requestChains returns the execution order of the suite's tests and, for stateful
+// suites, the request chain of every test by index. The chains of other suites are nil.
+func (s *TestSuite) requestChains() (order []int, chains [][]int, err error) {
+	order, err = executionOrder(s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chains = make([][]int, len(s.Tests))
+	if !s.IsStateful() {
+		return order, chains, nil
+	}
+
+	_, err = s.trackDependencies(order, func(tracker *DependencyTracker, i int) {
+		chains[i] = tracker.BuildRequestChain(i, s.Tests)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return order, chains, nil
+}
+
+// OrphanedRefs returns the refs that tests of the suite create but no test uses, in
+// sorted order (see DependencyTracker.FindOrphanedRefs)
+func (s *TestSuite) OrphanedRefs() ([]string, error) {
+	order, err := executionOrder(s)
+	if err != nil {
+		return nil, err
+	}
+	tracker, err := s.trackDependencies(order, func(*DependencyTracker, int) {})
+	if err != nil {
+		return nil, err
+	}
+	return tracker.FindOrphanedRefs(), nil
+}
+
+// trackDependencies feeds the tests of the suite to a DependencyTracker in the given
+// order as if they ran, calling visit for every test after its dependencies were built,
+// and returns the tracker
+func (s *TestSuite) trackDependencies(order []int, visit func(tracker *DependencyTracker, i int)) (*DependencyTracker, error) {
+	tracker := NewDependencyTracker()
+	for _, i := range order {
+		test := &s.Tests[i]
+		if err := tracker.BuildDependenciesForTest(i, test); err != nil {
+			return nil, fmt.Errorf("cannot resolve test dependencies: %w", err)
+		}
+		visit(tracker, i)
+		tracker.OnTestExecuted(i, test)
+	}
+	return tracker, nil
+}
+
+// keepTests removes the tests at the indices for which keep is false, preserving the
+// order of the others, and returns the number of tests kept
+func (s *TestSuite) keepTests(keep []bool) int {
+	var tests []TestCase
+	for i, test := range s.Tests {
+		if keep[i] {
+			tests = append(tests, test)
+		}
+	}
+	s.Tests = tests
+	return len(tests)
+}
@@ -0,0 +1,172 @@
+package runner
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// newFilterTestSuite returns a suite in which create#2 depends on create#1, and use#1
+// on create#2
+func newFilterTestSuite(stateful bool) TestSuite {
+	return TestSuite{
+		Stateful: stateful,
+		Tests: []TestCase{
+			{Request: Request{ID: "create#1", Method: "create", Ref: "$a"}},
+			{Request: Request{ID: "create#2", Method: "create", Ref: "$b", Params: json.RawMessage(`{"parent": {"ref": "$a"}}`)}},
+			{Request: Request{ID: "other#1", Method: "other"}},
+			{Request: Request{ID: "use#1", Method: "use", Params: json.RawMessage(`{"object": {"ref": "$b"}}`)}},
+			{Request: Request{ID: "use#2", Method: "use"}},
+		},
+	}
+}
+
+// testIDs returns the request IDs of the suite's tests
+func testIDs(suite TestSuite) []string {
+	var ids []string
+	for _, test := range suite.Tests {
+		ids = append(ids, test.Request.ID)
+	}
+	return ids
+}
+
+func TestTestSuite_FilterTests(t *testing.T) {
+	tests := []struct {
+		name     string
+		stateful bool
+		pattern  string
+		want     []string
+	}{
+		{name: "parallel", stateful: false, pattern: "use#*", want: []string{"use#1", "use#2"}},
+		{name: "stateful keeps transitive prerequisites", stateful: true, pattern: "use#1", want: []string{"create#1", "create#2", "use#1"}},
+		{name: "stateful without dependencies", stateful: true, pattern: "other#1", want: []string{"other#1"}},
+		{name: "no match", stateful: true, pattern: "missing", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suite := newFilterTestSuite(tt.stateful)
+			removed, err := suite.FilterTests(tt.pattern)
+			if err != nil {
+				t.Fatalf("FilterTests failed: %v", err)
+			}
+			if got := testIDs(suite); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kept %v, want %v", got, tt.want)
+			}
+			if removed != 5-len(tt.want) {
+				t.Errorf("removed = %d, want %d", removed, 5-len(tt.want))
+			}
+		})
+	}
+
+	suite := newFilterTestSuite(false)
+	if _, err := suite.FilterTests("use#["); err == nil {
+		t.Errorf("expected error for invalid pattern")
+	}
+}
+
+func TestTestSuite_FilterTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		stateful  bool
+		suiteTags []string
+		tags      []string
+		want      []string
+	}{
+		{name: "parallel", tags: []string{"slow"}, want: []string{"use#1", "use#2"}},
+		{name: "any tag", tags: []string{"signet", "slow"}, want: []string{"other#1", "use#1", "use#2"}},
+		{name: "stateful keeps prerequisites", stateful: true, tags: []string{"signet", "regtest"}, want: []string{"create#1", "create#2", "other#1"}},
+		{name: "suite tags", suiteTags: []string{"chain"}, tags: []string{"chain"}, want: []string{"create#1", "create#2", "other#1", "use#1", "use#2"}},
+		{name: "no match", tags: []string{"missing"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suite := newFilterTestSuite(tt.stateful)
+			suite.Tags = tt.suiteTags
+			suite.Tests[1].Tags = []string{"regtest"}
+			suite.Tests[2].Tags = []string{"signet"}
+			suite.Tests[3].Tags = []string{"slow"}
+			suite.Tests[4].Tags = []string{"fast", "slow"}
+
+			removed, err := suite.FilterTags(tt.tags)
+			if err != nil {
+				t.Fatalf("FilterTags failed: %v", err)
+			}
+			if got := testIDs(suite); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kept %v, want %v", got, tt.want)
+			}
+			if removed != 5-len(tt.want) {
+				t.Errorf("removed = %d, want %d", removed, 5-len(tt.want))
+			}
+		})
+	}
+}
+
+func TestTestSuite_FocusTests(t *testing.T) {
+	tests := []struct {
+		name     string
+		stateful bool
+		only     []int
+		want     []string
+	}{
+		{name: "no focus", only: nil, want: []string{"create#1", "create#2", "other#1", "use#1", "use#2"}},
+		{name: "parallel", only: []int{3, 4}, want: []string{"use#1", "use#2"}},
+		{name: "stateful keeps prerequisites", stateful: true, only: []int{3}, want: []string{"create#1", "create#2", "use#1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suite := newFilterTestSuite(tt.stateful)
+			for _, i := range tt.only {
+				suite.Tests[i].Only = true
+			}
+
+			removed, err := suite.FocusTests()
+			if err != nil {
+				t.Fatalf("FocusTests failed: %v", err)
+			}
+			if got := testIDs(suite); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kept %v, want %v", got, tt.want)
+			}
+			if removed != 5-len(tt.want) {
+				t.Errorf("removed = %d, want %d", removed, 5-len(tt.want))
+			}
+		})
+	}
+}
+
+func TestTestSuite_ExcludeTests(t *testing.T) {
+	tests := []struct {
+		name     string
+		stateful bool
+		pattern  string
+		want     []string
+	}{
+		{name: "parallel", stateful: false, pattern: "create#*", want: []string{"create#1", "create#2"}},
+		{name: "stateful excludes dependent tests", stateful: true, pattern: "create#1", want: []string{"create#1", "create#2", "use#1"}},
+		{name: "stateful without dependents", stateful: true, pattern: "use#1", want: []string{"use#1"}},
+		{name: "no match", stateful: true, pattern: "missing", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suite := newFilterTestSuite(tt.stateful)
+			excluded, err := suite.ExcludeTests(tt.pattern)
+			if err != nil {
+				t.Fatalf("ExcludeTests failed: %v", err)
+			}
+			if !reflect.DeepEqual(excluded, tt.want) {
+				t.Errorf("excluded %v, want %v", excluded, tt.want)
+			}
+			if got := len(suite.Tests); got != 5-len(tt.want) {
+				t.Errorf("kept %v, want %d tests", testIDs(suite), 5-len(tt.want))
+			}
+		})
+	}
+
+	suite := newFilterTestSuite(false)
+	if _, err := suite.ExcludeTests("use#["); err == nil {
+		t.Errorf("expected error for invalid pattern")
+	}
+}
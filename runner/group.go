@@ -0,0 +1,147 @@
+package runner
+
+import "context"
+
+// SetIsolateGroups controls whether the tests of each group (see TestCase.Group) share a
+// dedicated handler process within non-stateful suites. The group's handler is spawned
+// for its first test and closed after its last test; ungrouped tests keep using the
+// runner's handler. Defaults to false.
+func (tr *TestRunner) SetIsolateGroups(isolate bool) {
+	tr.isolateGroups = isolate
+}
+
+// handlerSlot holds a handler together with its cached version
+type handlerSlot struct {
+	handler HandlerInterface
+	version string
+}
+
+// testGroups tracks the groups of a non-stateful suite while it runs: the group hooks
+// (see TestCase.Group) and, with isolated groups, the Group-keyed pool of handlers
+type testGroups struct {
+	tests   []TestCase
+	first   map[string]int // Index of the first enabled test of each group
+	last    map[string]int // Index of the last enabled test of each group
+	started map[string]error
+	isolate bool
+	slots   map[string]handlerSlot
+}
+
+// newTestGroups returns the groups of the suite, or nil if the suite has no groups with
+// hooks or handlers to isolate
+func (tr *TestRunner) newTestGroups(suite *TestSuite) *testGroups {
+	if suite.IsStateful() {
+		return nil
+	}
+	first := make(map[string]int)
+	last := make(map[string]int)
+	for i, test := range suite.Tests {
+		if test.Group == "" || test.Disabled || test.Skip {
+			continue
+		}
+		if _, ok := first[test.Group]; !ok {
+			first[test.Group] = i
+		}
+		last[test.Group] = i
+	}
+
+	hooks := false
+	for group := range first {
+		if suite.Tests[first[group]].BeforeHook != nil || suite.Tests[last[group]].AfterHook != nil {
+			hooks = true
+		}
+	}
+	if len(first) == 0 || !(hooks || tr.isolateGroups) {
+		return nil
+	}
+	return &testGroups{
+		tests:   suite.Tests,
+		first:   first,
+		last:    last,
+		started: make(map[string]error),
+		isolate: tr.isolateGroups,
+		slots:   make(map[string]handlerSlot),
+	}
+}
+
+// enterGroup prepares running a test of a group. With isolated groups, it makes the
+// group's handler the current handler and returns the handler it replaced; a nil group
+// handler is spawned by the next SendRequest. If the group has not started yet, its
+// before hook runs. Returns the error of the group's before hook, if any.
+func (tr *TestRunner) enterGroup(ctx context.Context, groups *testGroups, group string) (handlerSlot, error) {
+	prev := handlerSlot{handler: tr.handler, version: tr.handlerVersion}
+	if groups.isolate {
+		slot := groups.slots[group]
+		tr.handler, tr.handlerVersion = slot.handler, slot.version
+	}
+
+	err, ok := groups.started[group]
+	if !ok {
+		if hook := groups.tests[groups.first[group]].BeforeHook; hook != nil {
+			err = hook(ctx)
+		}
+		groups.started[group] = err
+	}
+	return prev, err
+}
+
+// leaveGroup finishes running the test at index i of a group. If it was the last test
+// of the group, the group's after hook runs and, with isolated groups, the group's
+// handler is closed; otherwise the handler is stored back in the pool. The previous
+// handler is restored.
+func (tr *TestRunner) leaveGroup(ctx context.Context, groups *testGroups, group string, i int, prev handlerSlot) {
+	if groups.last[group] == i {
+		groups.finish(ctx, group)
+		if groups.isolate {
+			tr.CloseHandler()
+			delete(groups.slots, group)
+		}
+	} else if groups.isolate {
+		groups.slots[group] = handlerSlot{handler: tr.handler, version: tr.handlerVersion}
+	}
+	if groups.isolate {
+		tr.handler, tr.handlerVersion = prev.handler, prev.version
+	}
+}
+
+// finish runs the after hook of a started group
+func (groups *testGroups) finish(ctx context.Context, group string) {
+	if _, ok := groups.started[group]; !ok {
+		return
+	}
+	delete(groups.started, group)
+	if hook := groups.tests[groups.last[group]].AfterHook; hook != nil {
+		hook(ctx)
+	}
+}
+
+// withoutGroupHooks returns the test at index i with the hooks removed that run as group
+// hooks instead, as they must not run again around the test itself
+func (groups *testGroups) withoutGroupHooks(i int, test *TestCase) *TestCase {
+	if groups == nil || test.Group == "" {
+		return test
+	}
+	stripped := *test
+	if groups.first[test.Group] == i {
+		stripped.BeforeHook = nil
+	}
+	if groups.last[test.Group] == i {
+		stripped.AfterHook = nil
+	}
+	return &stripped
+}
+
+// closeAll runs the after hooks of the started groups whose last test has not run, and
+// closes their handlers, e.g. because the suite stopped early or the last test was
+// skipped
+func (groups *testGroups) closeAll(ctx context.Context) {
+	for group := range groups.started {
+		groups.finish(ctx, group)
+	}
+	for group, slot := range groups.slots {
+		if slot.handler != nil {
+			slot.handler.Close()
+		}
+		delete(groups.slots, group)
+	}
+}
@@ -0,0 +1,198 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRunTestSuite_IsolateGroups(t *testing.T) {
+	// Every handler instance answers with its own spawn number, so a test passes only if
+	// it ran against the expected handler
+	spawned := 0
+	newHandler := func() (HandlerInterface, error) {
+		instance := spawned
+		spawned++
+		return &inProcessHandler{fn: func(Request) Response {
+			return Response{Result: Result(strconv.Itoa(instance))}
+		}}, nil
+	}
+	tr := &TestRunner{newHandler: newHandler, timeout: 30 * time.Second}
+	tr.handler, _ = newHandler()
+	tr.SetIsolateGroups(true)
+	defer tr.CloseHandler()
+
+	test := func(id, group, expected string) TestCase {
+		return TestCase{
+			Request:          Request{ID: id, Method: "m"},
+			ExpectedResponse: Response{Result: Result(expected)},
+			Group:            group,
+		}
+	}
+	suite := TestSuite{
+		Name: "Groups",
+		Tests: []TestCase{
+			test("a", "g1", "1"),
+			test("b", "", "0"),
+			test("c", "g2", "2"),
+			test("d", "g1", "1"),
+			test("e", "", "0"),
+		},
+	}
+
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	if result.FailedTests != 0 {
+		t.Fatalf("expected all tests to run against their group's handler, got %+v", result.TestResults)
+	}
+	if spawned != 3 {
+		t.Errorf("expected one handler per group plus the runner's handler, got %d", spawned)
+	}
+
+	// Groups are not isolated unless enabled
+	tr.SetIsolateGroups(false)
+	result = tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	if result.PassedTests != 2 {
+		t.Errorf("expected only ungrouped tests to pass without isolation, got %d passed", result.PassedTests)
+	}
+}
+
+// closeCountingHandler counts how often the handler it wraps is closed
+type closeCountingHandler struct {
+	HandlerInterface
+	closed *int
+}
+
+func (h *closeCountingHandler) Close() {
+	*h.closed++
+	h.HandlerInterface.Close()
+}
+
+func TestRunTestSuite_IsolateGroupsClosesHandlerOfSkippedLastTest(t *testing.T) {
+	spawned, closed := 0, 0
+	newHandler := func() (HandlerInterface, error) {
+		spawned++
+		return &closeCountingHandler{
+			HandlerInterface: &inProcessHandler{fn: func(Request) Response {
+				return Response{Result: Result("true")}
+			}},
+			closed: &closed,
+		}, nil
+	}
+	tr := &TestRunner{newHandler: newHandler, timeout: 30 * time.Second}
+	tr.handler, _ = newHandler()
+	tr.SetIsolateGroups(true)
+
+	// The last test of the group is skipped at run time, as its ref creator is disabled
+	suite := TestSuite{
+		Name: "Groups",
+		Tests: []TestCase{
+			{Request: Request{ID: "a", Method: "m", Ref: "$x"}, Disabled: true},
+			{Request: Request{ID: "b", Method: "m"}, ExpectedResponse: Response{Result: Result("true")}, Group: "g"},
+			{Request: Request{ID: "c", Method: "m", Params: json.RawMessage(`{"x":{"ref":"$x"}}`)}, Group: "g"},
+		},
+	}
+
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	if result.PassedTests != 1 || result.SkippedTests != 1 {
+		t.Fatalf("expected 1 passed and 1 skipped test, got %+v", result.TestResults)
+	}
+	tr.CloseHandler()
+	if spawned != 2 || closed != spawned {
+		t.Errorf("expected every spawned handler to be closed, spawned %d, closed %d", spawned, closed)
+	}
+}
+
+func TestRunTestSuite_GroupHooks(t *testing.T) {
+	var events []string
+	attempts := make(map[string]int)
+	tr := &TestRunner{newHandler: func() (HandlerInterface, error) {
+		return &inProcessHandler{fn: func(req Request) Response {
+			events = append(events, req.ID)
+			attempts[req.ID]++
+			// The first test only passes when retried
+			return Response{Result: Result(strconv.FormatBool(req.ID != "a" || attempts[req.ID] > 1))}
+		}}, nil
+	}, timeout: 30 * time.Second}
+	defer tr.CloseHandler()
+
+	// The group hooks run once, even though the first test is retried and the last test
+	// is skipped at run time, as its ref creator is disabled
+	suite := TestSuite{
+		Name: "Group hooks",
+		Tests: []TestCase{
+			{Request: Request{ID: "x", Method: "m", Ref: "$x"}, Disabled: true},
+			{
+				Request:          Request{ID: "a", Method: "m"},
+				ExpectedResponse: Response{Result: Result("true")},
+				Group:            "g",
+				RetryCount:       1,
+				BeforeHook: func(context.Context) error {
+					events = append(events, "setup")
+					return nil
+				},
+			},
+			{Request: Request{ID: "b", Method: "m"}, ExpectedResponse: Response{Result: Result("true")}},
+			{
+				Request: Request{ID: "c", Method: "m", Params: json.RawMessage(`{"x":{"ref":"$x"}}`)},
+				Group:   "g",
+				AfterHook: func(context.Context) {
+					events = append(events, "teardown")
+				},
+			},
+		},
+	}
+
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	if result.PassedTests != 2 || result.SkippedTests != 1 {
+		t.Fatalf("expected 2 passed and 1 skipped test, got %+v", result.TestResults)
+	}
+	if want := []string{"setup", "a", "a", "b", "teardown"}; !slices.Equal(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+func TestRunTestSuite_GroupBeforeHookFails(t *testing.T) {
+	var events []string
+	tr := &TestRunner{newHandler: func() (HandlerInterface, error) {
+		return &inProcessHandler{fn: func(req Request) Response {
+			events = append(events, req.ID)
+			return Response{Result: Result("true")}
+		}}, nil
+	}, timeout: 30 * time.Second}
+	defer tr.CloseHandler()
+
+	suite := TestSuite{
+		Name: "Group hooks",
+		Tests: []TestCase{
+			{
+				Request: Request{ID: "a", Method: "m"},
+				Group:   "g",
+				BeforeHook: func(context.Context) error {
+					return errors.New("no fixture")
+				},
+			},
+			{
+				Request: Request{ID: "b", Method: "m"},
+				Group:   "g",
+				AfterHook: func(context.Context) {
+					events = append(events, "teardown")
+				},
+			},
+		},
+	}
+
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	for _, r := range result.TestResults {
+		if r.Passed || r.Message != "group before hook failed: no fixture" {
+			t.Errorf("expected %s to fail with the group before hook, got %q", r.TestID, r.Message)
+		}
+	}
+	// No request is sent, but the group is still torn down
+	if want := []string{"teardown"}; !slices.Equal(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
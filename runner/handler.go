@@ -3,11 +3,14 @@ package runner
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os/exec"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -16,30 +19,92 @@ var (
 	ErrHandlerTimeout = errors.New("handler timeout")
 	// ErrHandlerClosed indicates the handler closed stdout unexpectedly
 	ErrHandlerClosed = errors.New("handler closed unexpectedly")
+	// ErrLineTooLong indicates a handler response line exceeds HandlerConfig.BufferSize
+	ErrLineTooLong = errors.New("handler response line too long")
 )
 
+// MaxLineSize is the largest HandlerConfig.BufferSize, and its default: the maximum size
+// in bytes of a single response line read from a handler process
+const MaxLineSize = 64 << 20
+
+// HandlerInterface is the line-oriented transport the test runner uses to talk to a
+// handler. Handler implements it for subprocess handlers communicating over stdin/stdout.
+type HandlerInterface interface {
+	// SendLine sends a single request line to the handler
+	SendLine(line []byte) error
+	// ReadLine reads a single response line from the handler
+	ReadLine() ([]byte, error)
+	// Close releases the handler's resources
+	Close()
+}
+
 // HandlerConfig configures a handler process
 type HandlerConfig struct {
 	Path string
 	Args []string
 	Env  []string
-	// Timeout specifies the maximum duration to wait when reading from the handler's
-	// stdout. If zero, defaults to 10 seconds. The handler is killed if it fails to
-	// write output within this timeout.
+	// Logger receives diagnostics about the handler process. If nil, defaults to
+	// slog.Default().
+	Logger *slog.Logger
+	// Delimiter terminates the response lines the handler writes to stdout. If zero,
+	// defaults to '\n'. A carriage return preceding the delimiter is stripped, so
+	// handlers emitting \r\n line endings work either way.
+	Delimiter byte
+	// Streaming lets a TestRunner send all requests of a parallel suite without waiting
+	// for responses, for handlers that process requests concurrently. Responses may
+	// arrive in any order and must carry the id of their request.
+	Streaming bool
+	// EscapeHTML controls whether a TestRunner escapes <, >, and & in the requests it
+	// sends as \u003c, \u003e, and \u0026, like json.Marshal does. NewTestRunner
+	// enables it; disable it for handlers that expect these characters literally.
+	EscapeHTML bool
+	// Timeout specifies the maximum duration to wait for a response line from the
+	// handler's stdout. If zero, defaults to 10 seconds. The handler is killed if it fails
+	// to write a complete line within this timeout.
 	Timeout time.Duration
+	// CloseTimeout specifies how long Close waits for the handler to exit after its stdin
+	// was closed. If zero, defaults to 5 seconds. The handler is killed if it does not
+	// exit within this timeout.
+	CloseTimeout time.Duration
+	// BufferSize is the maximum size in bytes of a response line the handler may write,
+	// e.g. to reject runaway output early. If zero, defaults to MaxLineSize, which is also
+	// the largest accepted value. Reading a longer line fails with ErrLineTooLong.
+	BufferSize int
+	// ReadySignal, if set, makes NewHandler wait until the handler writes a matching line
+	// to stdout before returning, for handlers that must load state before they accept
+	// requests. A line matches if it equals ReadySignal, or if ReadySignal is a regular
+	// expression matching the whole line. Lines before it are discarded. NewHandler fails
+	// if no line matches within Timeout.
+	ReadySignal string
 }
 
 // Handler manages a conformance handler process communicating via stdin/stdout
 type Handler struct {
-	cmd     *exec.Cmd
-	stdin   io.WriteCloser
-	stdout  *bufio.Scanner
-	stderr  io.ReadCloser
-	timeout time.Duration
+	cmd          *exec.Cmd
+	stdin        io.WriteCloser
+	stdout       *bufio.Reader
+	timeout      time.Duration
+	closeTimeout time.Duration
+	logger       *slog.Logger
+	delimiter    byte
+	maxLineSize  int
+
+	// stderr holds the most recent lines the handler wrote to stderr. stderrDone is
+	// closed once stderr has been read to the end.
+	stderr     *stderrBuffer
+	stderrDone chan struct{}
+
+	// exitErr records why the handler did not exit cleanly when closed (nil if it did)
+	exitErr error
+	closed  bool
 }
 
 // NewHandler spawns a new handler process with the given configuration
 func NewHandler(cfg *HandlerConfig) (*Handler, error) {
+	if cfg.BufferSize < 0 || cfg.BufferSize > MaxLineSize {
+		return nil, fmt.Errorf("buffer size %d is not between 1 and %d bytes", cfg.BufferSize, MaxLineSize)
+	}
+
 	cmd := exec.Command(cfg.Path, cfg.Args...)
 	if cfg.Env != nil {
 		cmd.Env = append(cmd.Environ(), cfg.Env...)
@@ -70,13 +135,69 @@ func NewHandler(cfg *HandlerConfig) (*Handler, error) {
 		timeout = 10 * time.Second
 	}
 
-	return &Handler{
-		cmd:     cmd,
-		stdin:   stdin,
-		stdout:  bufio.NewScanner(stdout),
-		stderr:  stderr,
-		timeout: timeout,
-	}, nil
+	closeTimeout := cfg.CloseTimeout
+	if closeTimeout == 0 {
+		closeTimeout = 5 * time.Second
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	delimiter := cfg.Delimiter
+	if delimiter == 0 {
+		delimiter = '\n'
+	}
+
+	maxLineSize := cfg.BufferSize
+	if maxLineSize == 0 {
+		maxLineSize = MaxLineSize
+	}
+
+	h := &Handler{
+		cmd:          cmd,
+		stdin:        stdin,
+		stdout:       bufio.NewReader(stdout),
+		timeout:      timeout,
+		closeTimeout: closeTimeout,
+		logger:       logger,
+		delimiter:    delimiter,
+		maxLineSize:  maxLineSize,
+		stderr:       &stderrBuffer{},
+		stderrDone:   make(chan struct{}),
+	}
+	// Drain stderr continuously so that a chatty handler never blocks on a full pipe
+	go h.stderr.capture(stderr, h.stderrDone)
+
+	if cfg.ReadySignal != "" {
+		if err := h.waitReady(cfg.ReadySignal); err != nil {
+			h.Close()
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// waitReady reads and discards stdout lines until one matches signal (see
+// HandlerConfig.ReadySignal). The handler is killed if none does within the handler
+// timeout.
+func (h *Handler) waitReady(signal string) error {
+	// A signal that is not a valid regular expression can still match exactly
+	pattern, _ := regexp.Compile("^(?:" + signal + ")$")
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+	for {
+		line, err := h.readLine(ctx)
+		if err != nil {
+			return fmt.Errorf("handler did not signal readiness with %q within %v: %w", signal, h.timeout, err)
+		}
+		if string(line) == signal || (pattern != nil && pattern.Match(line)) {
+			return nil
+		}
+		h.logger.Debug("Discarding handler output before ready signal", "line", string(line))
+	}
 }
 
 // SendLine writes a line to the handler's stdin
@@ -85,47 +206,186 @@ func (h *Handler) SendLine(line []byte) error {
 	return err
 }
 
-// ReadLine reads a line from the handler's stdout with a configurable timeout
+// ReadLine reads a line from the handler's stdout with a configurable timeout. The
+// delimiter and a carriage return preceding it are stripped.
 func (h *Handler) ReadLine() ([]byte, error) {
-	// Use a timeout for Scan() in case the handler hangs
-	scanDone := make(chan bool, 1)
+	return h.readLine(context.Background())
+}
+
+// readLine is ReadLine, killing the handler when ctx is done before a line was read
+func (h *Handler) readLine(ctx context.Context) ([]byte, error) {
+	r, err := h.ReadLargeResponse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	line, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(line, []byte("\r")), nil
+}
+
+// ReadLargeResponse returns a reader that streams the next response line from the
+// handler's stdout, without the trailing delimiter, so that callers such as a
+// json.Decoder can consume it in chunks; ReadLine reads it into memory as a whole. A
+// carriage return before the delimiter is passed through, as JSON treats it as
+// whitespace. The reader fails with ErrLineTooLong once the line exceeds the handler's
+// buffer size. The handler timeout applies to the whole line; when it elapses before the line
+// was read, or when ctx is done, the handler is killed. Closing the reader discards the
+// rest of the line.
+func (h *Handler) ReadLargeResponse(ctx context.Context) (io.ReadCloser, error) {
+	deadline := time.Now().Add(h.timeout)
+	if err := h.fill(ctx, deadline); err != nil {
+		if errors.Is(err, io.EOF) {
+			// Handler closed stdout prematurely
+			return nil, h.fail(ErrHandlerClosed)
+		}
+		return nil, err
+	}
+	return &responseReader{h: h, ctx: ctx, deadline: deadline}, nil
+}
+
+// fill waits until the handler's stdout has buffered output, the deadline passes, or ctx
+// is done. On timeout or cancellation, the handler is killed.
+func (h *Handler) fill(ctx context.Context, deadline time.Time) error {
+	// Peek in the background in case the handler hangs
+	peekDone := make(chan error, 1)
 	go func() {
-		scanDone <- h.stdout.Scan()
+		_, err := h.stdout.Peek(1)
+		peekDone <- err
 	}()
 
-	var baseErr error
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
 	select {
-	case ok := <-scanDone:
-		if ok {
-			return h.stdout.Bytes(), nil
-		}
-		if err := h.stdout.Err(); err != nil {
-			return nil, err
-		}
-		// EOF - handler closed stdout prematurely, fall through to kill and capture stderr
-		baseErr = ErrHandlerClosed
-	case <-time.After(h.timeout):
-		// Timeout - handler didn't respond, fall through to kill and capture stderr
-		baseErr = ErrHandlerTimeout
+	case err := <-peekDone:
+		return err
+	case <-timer.C:
+		return h.fail(ErrHandlerTimeout)
+	case <-ctx.Done():
+		return h.fail(ctx.Err())
 	}
+}
 
+// fail kills the handler and returns baseErr annotated with the handler's stderr output
+func (h *Handler) fail(baseErr error) error {
 	// Kill the process immediately to force stderr to close.
 	// Without this, there's a rare scenario where stdout closes but stderr remains open,
-	// causing io.ReadAll(h.stderr) below to block indefinitely waiting for stderr EOF.
+	// causing the wait for stderr below to block indefinitely waiting for stderr EOF.
+	h.kill()
+
+	// Include stderr to provide diagnostic information when the handler fails.
+	<-h.stderrDone
+	lines, _ := h.stderr.since(0)
+	if stderrOut := strings.TrimSpace(strings.Join(lines, "\n")); stderrOut != "" {
+		return fmt.Errorf("%w: %s", baseErr, stderrOut)
+	}
+	return baseErr
+}
+
+// setTimeout replaces the timeout of subsequent reads and returns the previous one
+func (h *Handler) setTimeout(timeout time.Duration) time.Duration {
+	previous := h.timeout
+	h.timeout = timeout
+	return previous
+}
+
+// kill kills the handler process, which fails pending and later reads and writes. It is
+// safe to call concurrently with them.
+func (h *Handler) kill() {
 	if h.cmd.Process != nil {
 		h.cmd.Process.Kill()
 	}
+}
+
+// exited reports whether the handler process has exited, which is assumed once its
+// stderr has been read to the end
+func (h *Handler) exited() bool {
+	select {
+	case <-h.stderrDone:
+		return true
+	default:
+		return false
+	}
+}
+
+// stderrSince returns the stderr lines the handler wrote after the first n lines, and
+// the total number of lines written so far
+func (h *Handler) stderrSince(n int) ([]string, int) {
+	return h.stderr.since(n)
+}
+
+// responseReader streams a single response line from a handler's stdout
+type responseReader struct {
+	h        *Handler
+	ctx      context.Context
+	deadline time.Time // When the handler times out unless the line was read
+	size     int       // Bytes of the line read so far
+	done     bool      // Whether the end of the line has been reached
+	err      error     // Sticky read error
+}
+
+// Read reads from the response line, returning io.EOF at its end
+func (r *responseReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.done {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if err := r.h.fill(r.ctx, r.deadline); err != nil {
+		if errors.Is(err, io.EOF) {
+			// The last line may lack a trailing newline
+			r.done = true
+			return 0, io.EOF
+		}
+		r.err = err
+		return 0, err
+	}
 
-	// Capture stderr to provide diagnostic information when the handler fails.
-	if stderrOut, err := io.ReadAll(h.stderr); err == nil && len(stderrOut) > 0 {
-		return nil, fmt.Errorf("%w: %s", baseErr, bytes.TrimSpace(stderrOut))
+	buf, _ := r.h.stdout.Peek(r.h.stdout.Buffered())
+	line := buf
+	if i := bytes.IndexByte(buf, r.h.delimiter); i >= 0 {
+		line = buf[:i]
 	}
-	return nil, baseErr
+	n := copy(p, line)
+	discard := n
+	if n == len(line) && len(line) < len(buf) {
+		// Consume the delimiter as well
+		discard++
+		r.done = true
+	}
+	r.h.stdout.Discard(discard)
+
+	r.size += n
+	if r.size > r.h.maxLineSize {
+		// The rest of the line cannot be skipped reliably, so the handler is not reused
+		r.err = r.h.fail(ErrLineTooLong)
+		return n, r.err
+	}
+	return n, nil
+}
+
+// Close discards the unread rest of the response line
+func (r *responseReader) Close() error {
+	_, err := io.Copy(io.Discard, r)
+	return err
 }
 
-// Close closes stdin and waits for the handler to exit with a 5-second timeout.
-// If the handler doesn't exit within the timeout, it is killed.
+// Close closes stdin and waits for the handler to exit, at most for the configured close
+// timeout. If the handler doesn't exit within the timeout, it is killed. Closing a closed handler
+// does nothing.
 func (h *Handler) Close() {
+	if h.closed {
+		return
+	}
+	h.closed = true
 	if h.stdin != nil {
 		// Close stdin to signal the handler that we're done sending requests.
 		// Per the handler specification, the handler should exit cleanly when stdin closes.
@@ -137,22 +397,96 @@ func (h *Handler) Close() {
 		// Use a timeout in case the handler doesn't respect the protocol.
 		done := make(chan error, 1)
 		go func() {
+			// Wait must not be called before all reads from stderr have completed
+			<-h.stderrDone
 			done <- h.cmd.Wait()
 		}()
 
 		select {
 		case err := <-done:
 			if err != nil {
-				slog.Warn("Handler exit with error", "error", err)
+				h.logger.Warn("Handler exit with error", "error", err)
+				h.exitErr = err
 			}
-		case <-time.After(5 * time.Second):
-			slog.Warn("Handler did not exit within a 5-second timeout, killing process")
+		case <-time.After(h.closeTimeout):
+			h.logger.Warn("Handler did not exit within the close timeout, killing process", "close_timeout", h.closeTimeout)
+			h.exitErr = fmt.Errorf("handler did not exit within %v after stdin was closed", h.closeTimeout)
 			if h.cmd.Process != nil {
 				h.cmd.Process.Kill()
-				// Call Wait() again to let the process finish cleanup (closing pipes, etc.)
-				// No timeout needed since Kill() should guarantee the process will exit
-				h.cmd.Wait()
+				// Let the pending Wait() finish cleanup (closing pipes, etc.); calling it
+				// again would race with it. No timeout needed since Kill() should
+				// guarantee the process will exit.
+				<-done
 			}
 		}
 	}
 }
+
+// HandlerPool is a fixed-size pool of handler processes, which lets suites run
+// concurrently without spawning a handler for each of them. Handlers are spawned when
+// first acquired, and a handler that exited, e.g. because it crashed or was closed, is
+// replaced by a fresh one when next acquired.
+type HandlerPool struct {
+	cfg *HandlerConfig
+	// idle holds one entry per handler that is not acquired; nil entries have not been
+	// spawned yet
+	idle chan *Handler
+}
+
+// NewHandlerPool creates a pool of size handlers with the given configuration
+func NewHandlerPool(cfg *HandlerConfig, size int) (*HandlerPool, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("handler pool size must be at least 1, got %d", size)
+	}
+	idle := make(chan *Handler, size)
+	for range size {
+		idle <- nil
+	}
+	return &HandlerPool{cfg: cfg, idle: idle}, nil
+}
+
+// PooledHandler is a handler acquired from a HandlerPool
+type PooledHandler struct {
+	*Handler
+	pool *HandlerPool
+}
+
+// Acquire blocks until a handler of the pool is available or ctx is done. The handler
+// must be given back with Release once it is no longer used.
+func (p *HandlerPool) Acquire(ctx context.Context) (*PooledHandler, error) {
+	var h *Handler
+	select {
+	case h = <-p.idle:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if h == nil {
+		var err error
+		if h, err = NewHandler(p.cfg); err != nil {
+			p.idle <- nil
+			return nil, err
+		}
+	}
+	return &PooledHandler{Handler: h, pool: p}, nil
+}
+
+// Release gives the handler back to its pool. A handler that exited is closed, and
+// replaced by a fresh one when the pool next hands it out.
+func (ph *PooledHandler) Release() {
+	h := ph.Handler
+	if h.exited() {
+		h.Close()
+		h = nil
+	}
+	ph.pool.idle <- h
+}
+
+// Close closes the idle handlers of the pool, waiting for acquired handlers to be
+// released. The pool cannot be used afterwards.
+func (p *HandlerPool) Close() {
+	for range cap(p.idle) {
+		if h := <-p.idle; h != nil {
+			h.Close()
+		}
+	}
+}
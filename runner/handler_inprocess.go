@@ -0,0 +1,44 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// inProcessHandler implements HandlerInterface by calling a Go function directly.
+// SendLine decodes the request and invokes the function synchronously; ReadLine
+// returns the encoded response produced by the preceding SendLine.
+type inProcessHandler struct {
+	fn      func(req Request) Response
+	pending [][]byte
+}
+
+// SendLine decodes a request line and queues the handler function's response
+func (h *inProcessHandler) SendLine(line []byte) error {
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return fmt.Errorf("failed to unmarshal request: %w", err)
+	}
+
+	respData, err := json.Marshal(h.fn(req))
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+	h.pending = append(h.pending, respData)
+	return nil
+}
+
+// ReadLine returns the oldest queued response
+func (h *inProcessHandler) ReadLine() ([]byte, error) {
+	if len(h.pending) == 0 {
+		return nil, ErrHandlerClosed
+	}
+	line := h.pending[0]
+	h.pending = h.pending[1:]
+	return line, nil
+}
+
+// Close discards any queued responses
+func (h *inProcessHandler) Close() {
+	h.pending = nil
+}
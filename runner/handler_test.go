@@ -2,8 +2,11 @@ package runner
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"testing"
@@ -21,6 +24,14 @@ const (
 	helperNameNormal       = "normal"
 	helperNameUnresponsive = "unresponsive"
 	helperNameCrash        = "crash"
+	helperNameCompliant    = "compliant"
+	helperNameLarge        = "large"
+	helperNameLogging      = "logging"
+	helperNameRaw          = "raw"
+	helperNameCRLF         = "crlf"
+	helperNameConcurrent   = "concurrent"
+	helperNameReady        = "ready"
+	helperNameTrickle      = "trickle"
 )
 
 // testHelpers maps helper names to functions that simulate different handler behaviors.
@@ -28,6 +39,14 @@ var testHelpers = map[string]func(){
 	helperNameNormal:       helperNormal,
 	helperNameUnresponsive: helperUnresponsive,
 	helperNameCrash:        helperCrash,
+	helperNameCompliant:    helperCompliant,
+	helperNameLarge:        helperLarge,
+	helperNameLogging:      helperLogging,
+	helperNameRaw:          helperRaw,
+	helperNameCRLF:         helperCRLF,
+	helperNameConcurrent:   helperConcurrent,
+	helperNameReady:        helperReady,
+	helperNameTrickle:      helperTrickle,
 }
 
 // TestMain allows the test binary to serve two purposes:
@@ -139,6 +158,138 @@ func helperUnresponsive() {
 	}
 }
 
+// TestHandler_TrickleTimeout tests that the timeout applies to the whole response line,
+// so a handler that keeps writing without ever finishing the line is killed
+func TestHandler_TrickleTimeout(t *testing.T) {
+	h, err := newHandlerForTest(t, helperNameTrickle, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	defer h.Close()
+
+	if err := h.SendLine([]byte(`{"id":1,"method":"test"}`)); err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+
+	start := time.Now()
+	_, err = h.ReadLine()
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrHandlerTimeout) {
+		t.Errorf("Expected ErrHandlerTimeout, got: %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("Timeout took too long: %v (expected ~200ms)", elapsed)
+	}
+}
+
+// helperTrickle simulates a handler that starts a response but then writes one byte at
+// a time, never finishing the line
+func helperTrickle() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fmt.Print(`{"result":`)
+		for {
+			time.Sleep(20 * time.Millisecond)
+			fmt.Print(" ")
+		}
+	}
+}
+
+// TestHandler_CloseTimeout tests that Close kills a handler that does not exit within
+// the configured close timeout
+func TestHandler_CloseTimeout(t *testing.T) {
+	h, err := NewHandler(&HandlerConfig{
+		Path:         os.Args[0],
+		Env:          []string{"TEST_AS_SUBPROCESS=1", "TEST_HELPER_NAME=" + helperNameUnresponsive},
+		CloseTimeout: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+
+	// Keep the handler busy so that it does not exit when stdin closes
+	if err := h.SendLine([]byte(`{"id":1,"method":"test"}`)); err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+
+	start := time.Now()
+	h.Close()
+	elapsed := time.Since(start)
+
+	if elapsed > 2*time.Second {
+		t.Errorf("Close took too long: %v (expected ~100ms)", elapsed)
+	}
+	if h.exitErr == nil || !strings.Contains(h.exitErr.Error(), "100ms") {
+		t.Errorf("Expected close timeout error, got: %v", h.exitErr)
+	}
+}
+
+// TestHandler_ReadySignal tests that NewHandler waits for the ready signal, matched
+// exactly or as a regular expression, and fails if it never arrives
+func TestHandler_ReadySignal(t *testing.T) {
+	// The readiness wait covers spawning the test binary, which can be slow under -race;
+	// only the missing signal case waits out the timeout
+	tests := []struct {
+		name    string
+		signal  string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{name: "exact", signal: "ready (v1)", timeout: 5 * time.Second},
+		{name: "regex", signal: `ready \(v\d+\)`, timeout: 5 * time.Second},
+		{name: "missing", signal: "ready", timeout: 200 * time.Millisecond, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewHandler(&HandlerConfig{
+				Path:        os.Args[0],
+				Env:         []string{"TEST_AS_SUBPROCESS=1", "TEST_HELPER_NAME=" + helperNameReady},
+				Timeout:     tt.timeout,
+				ReadySignal: tt.signal,
+			})
+			if tt.wantErr {
+				if err == nil {
+					h.Close()
+					t.Fatal("Expected error for missing ready signal, got nil")
+				}
+				if !strings.Contains(err.Error(), "did not signal readiness") {
+					t.Errorf("Expected readiness error, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to create handler: %v", err)
+			}
+			defer h.Close()
+
+			// The first line read must be the response, not output before the signal
+			if err := h.SendLine([]byte(`{"id":1,"method":"test"}`)); err != nil {
+				t.Fatalf("Failed to send request: %v", err)
+			}
+			line, err := h.ReadLine()
+			if err != nil {
+				t.Fatalf("Failed to read response: %v", err)
+			}
+			if string(line) != `{"result":true}` {
+				t.Errorf("Expected response line, got %q", line)
+			}
+		})
+	}
+}
+
+// helperReady simulates a handler that writes status lines while loading, and then a
+// ready signal, before responding with true to every request
+func helperReady() {
+	fmt.Println("loading")
+	fmt.Println("ready (v1)")
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fmt.Println(`{"result":true}`)
+	}
+}
+
 // TestHandler_Crash tests that the runner correctly handles a handler that crashes
 // while processing a request
 func TestHandler_Crash(t *testing.T) {
@@ -182,6 +333,213 @@ func helperCrash() {
 	}
 }
 
+// TestHandler_ReadLargeResponse tests that responses larger than a bufio.Scanner token
+// can be streamed and decoded, and that the stream stays aligned after a malformed one
+func TestHandler_ReadLargeResponse(t *testing.T) {
+	h, err := newHandlerForTest(t, helperNameLarge, 0)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	defer h.Close()
+
+	if err := h.SendLine([]byte(`{"id":"1","method":"large"}`)); err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	r, err := h.ReadLargeResponse(context.Background())
+	if err != nil {
+		t.Fatalf("ReadLargeResponse() error: %v", err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("Failed to read streamed response: %v", err)
+	}
+	if want := len(`{"result":""}`) + largeResultSize; len(data) != want {
+		t.Fatalf("Expected %d bytes, got %d", want, len(data))
+	}
+
+	tr := &TestRunner{handler: h}
+	for _, method := range []string{"malformed", "large"} {
+		if err := h.SendLine([]byte(`{"id":"2","method":"` + method + `"}`)); err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+	}
+	if _, err := tr.ReadResponse(); err == nil {
+		t.Fatal("Expected error decoding malformed response")
+	}
+	resp, err := tr.ReadResponse()
+	if err != nil {
+		t.Fatalf("ReadResponse() error: %v", err)
+	}
+	if len(resp.Result) != largeResultSize+2 {
+		t.Errorf("Expected a %d byte result, got %d bytes", largeResultSize+2, len(resp.Result))
+	}
+}
+
+// TestHandler_BufferSize tests that response lines longer than the configured buffer size
+// are rejected, and that buffer sizes above MaxLineSize are refused
+func TestHandler_BufferSize(t *testing.T) {
+	h, err := NewHandler(&HandlerConfig{
+		Path:       os.Args[0],
+		Env:        []string{"TEST_AS_SUBPROCESS=1", "TEST_HELPER_NAME=" + helperNameLarge},
+		BufferSize: largeResultSize / 2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	defer h.Close()
+
+	if err := h.SendLine([]byte(`{"id":"1","method":"large"}`)); err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	if _, err := h.ReadLine(); !errors.Is(err, ErrLineTooLong) {
+		t.Errorf("Expected ErrLineTooLong, got: %v", err)
+	}
+	if !h.exited() {
+		t.Errorf("Expected the handler to be killed after a line that is too long")
+	}
+
+	if _, err := NewHandler(&HandlerConfig{Path: os.Args[0], BufferSize: MaxLineSize + 1}); err == nil {
+		t.Errorf("Expected an error for a buffer size above MaxLineSize")
+	}
+}
+
+// largeResultSize is the size of the string result returned by helperLarge, well above
+// the default 64 KiB token size of bufio.Scanner
+const largeResultSize = 1 << 20
+
+// helperLarge simulates a handler that answers "large" requests with a large string
+// result, and any other request with a line that is not valid JSON
+func helperLarge() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if strings.Contains(scanner.Text(), `"large"`) {
+			fmt.Printf(`{"result":"%s"}`+"\n", strings.Repeat("a", largeResultSize))
+		} else {
+			fmt.Printf(`{"result":"unterminated}` + "\n")
+		}
+	}
+}
+
+// TestHandler_NoHTMLEscape tests that requests contain HTML characters literally when
+// HTML escaping is disabled, and escaped otherwise
+func TestHandler_NoHTMLEscape(t *testing.T) {
+	tests := []struct {
+		escapeHTML bool
+		want       string
+	}{
+		{escapeHTML: false, want: `{"s":"<script>&</script>"}`},
+		{escapeHTML: true, want: `{"s":"\u003cscript\u003e\u0026\u003c/script\u003e"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("escapeHTML=%v", tt.escapeHTML), func(t *testing.T) {
+			h, err := newHandlerForTest(t, helperNameRaw, 0)
+			if err != nil {
+				t.Fatalf("Failed to create handler: %v", err)
+			}
+			tr := &TestRunner{handler: h}
+			defer tr.CloseHandler()
+			tr.SetEscapeHTML(tt.escapeHTML)
+
+			req := Request{ID: "1", Method: "raw", Params: json.RawMessage(`{"s": "<script>&</script>"}`)}
+			if err := tr.SendRequest(req); err != nil {
+				t.Fatalf("Failed to send request: %v", err)
+			}
+			resp, err := tr.ReadResponse()
+			if err != nil {
+				t.Fatalf("Failed to read response: %v", err)
+			}
+
+			// The helper responds with the request line it received as a string
+			var received string
+			if err := json.Unmarshal(resp.Result, &received); err != nil {
+				t.Fatalf("Failed to decode received line: %v", err)
+			}
+			if !strings.Contains(received, tt.want) {
+				t.Errorf("expected received request to contain %s, got %s", tt.want, received)
+			}
+		})
+	}
+}
+
+// helperRaw simulates a handler that responds with the raw request line it received
+// as a string result
+func helperRaw() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		result, _ := json.Marshal(scanner.Text())
+		fmt.Printf(`{"result":%s}`+"\n", result)
+	}
+}
+
+// TestHandler_Delimiter tests that responses terminated by \r\n or by a custom
+// delimiter are read and unmarshaled correctly
+func TestHandler_Delimiter(t *testing.T) {
+	tests := []struct {
+		name      string
+		delimiter byte
+		env       string
+	}{
+		{name: "crlf"},
+		{name: "record separator", delimiter: 0x1e, env: "CRLF_DELIMITER=\x1e"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := []string{"TEST_AS_SUBPROCESS=1", "TEST_HELPER_NAME=" + helperNameCRLF}
+			if tt.env != "" {
+				env = append(env, tt.env)
+			}
+			h, err := NewHandler(&HandlerConfig{
+				Path:      os.Args[0],
+				Env:       env,
+				Delimiter: tt.delimiter,
+			})
+			if err != nil {
+				t.Fatalf("Failed to create handler: %v", err)
+			}
+			tr := &TestRunner{handler: h}
+			defer tr.CloseHandler()
+
+			for _, id := range []string{"1", "2"} {
+				if err := h.SendLine([]byte(`{"id":"` + id + `","method":"m"}`)); err != nil {
+					t.Fatalf("Failed to send request: %v", err)
+				}
+			}
+
+			line, err := h.ReadLine()
+			if err != nil {
+				t.Fatalf("ReadLine() error: %v", err)
+			}
+			if string(line) != `{"result":true}` {
+				t.Errorf("expected delimiter and carriage return to be stripped, got %q", line)
+			}
+
+			resp, err := tr.ReadResponse()
+			if err != nil {
+				t.Fatalf("ReadResponse() error: %v", err)
+			}
+			if string(resp.Result) != "true" {
+				t.Errorf("expected result true, got %s", resp.Result)
+			}
+		})
+	}
+}
+
+// helperCRLF simulates a Windows-native handler that terminates every response with
+// \r followed by the delimiter in CRLF_DELIMITER, or \n if unset
+func helperCRLF() {
+	delimiter := os.Getenv("CRLF_DELIMITER")
+	if delimiter == "" {
+		delimiter = "\n"
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fmt.Print(`{"result":true}` + "\r" + delimiter)
+	}
+}
+
 // newHandlerForTest creates a Handler that runs a test helper as a subprocess.
 // The helperName identifies which helper to run (e.g., "normal", "crash", "hang").
 // The timeout parameter sets the per-request timeout (0 uses default).
@@ -194,3 +552,54 @@ func newHandlerForTest(t *testing.T, helperName string, timeout time.Duration) (
 		Timeout: timeout,
 	})
 }
+
+// TestHandlerPool tests that pooled handlers are reused, and replaced once they exited
+func TestHandlerPool(t *testing.T) {
+	pool, err := NewHandlerPool(&HandlerConfig{
+		Path: os.Args[0],
+		Env:  []string{"TEST_AS_SUBPROCESS=1", "TEST_HELPER_NAME=" + helperNameNormal},
+	}, 1)
+	if err != nil {
+		t.Fatalf("Failed to create handler pool: %v", err)
+	}
+	defer pool.Close()
+
+	first, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to acquire handler: %v", err)
+	}
+
+	// The only handler is in use, so acquiring another one blocks until ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected acquire to time out, got: %v", err)
+	}
+
+	first.Release()
+	second, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to acquire handler: %v", err)
+	}
+	if second.Handler != first.Handler {
+		t.Errorf("Expected the released handler to be reused")
+	}
+
+	// A handler that exited is replaced by a fresh one
+	second.Close()
+	second.Release()
+	third, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to acquire handler: %v", err)
+	}
+	defer third.Release()
+	if third.Handler == second.Handler {
+		t.Fatalf("Expected the closed handler to be replaced")
+	}
+	if err := third.SendLine([]byte(`{"id":1,"method":"test"}`)); err != nil {
+		t.Fatalf("Failed to send to replacement handler: %v", err)
+	}
+	if _, err := third.ReadLine(); err != nil {
+		t.Errorf("Failed to read from replacement handler: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	// logEntryGracePeriod is how long to wait for an expected log entry after the
+	// response was read, since stderr is read independently of stdout
+	logEntryGracePeriod = 200 * time.Millisecond
+
+	// logEntryContextLines is the number of recent stderr lines attached to a missing
+	// log entry failure
+	logEntryContextLines = 10
+)
+
+// stderrCapturer is implemented by handlers that capture their stderr output
+type stderrCapturer interface {
+	stderrSince(n int) ([]string, int)
+}
+
+// stderrMark returns the number of stderr lines the current handler has written so far,
+// to be passed to checkLogEntry once the test has run
+func (tr *TestRunner) stderrMark() int {
+	capturer, ok := tr.handler.(stderrCapturer)
+	if !ok {
+		return 0
+	}
+	_, total := capturer.stderrSince(0)
+	return total
+}
+
+// handlerStderr returns what the current handler wrote to stderr after the first mark
+// lines, as read so far. Returns an empty string if the handler does not capture stderr.
+func (tr *TestRunner) handlerStderr(mark int) string {
+	capturer, ok := tr.handler.(stderrCapturer)
+	if !ok {
+		return ""
+	}
+	lines, _ := capturer.stderrSince(mark)
+	return strings.Join(lines, "\n")
+}
+
+// checkLogEntry verifies that the handler wrote a JSON object line containing all
+// key-value pairs of expected to stderr after the first mark lines. Returns an error
+// including the most recent stderr lines if no such line appears within
+// logEntryGracePeriod.
+func (tr *TestRunner) checkLogEntry(expected map[string]any, mark int) error {
+	capturer, ok := tr.handler.(stderrCapturer)
+	if !ok {
+		return fmt.Errorf("expected log entry cannot be checked: handler does not capture stderr")
+	}
+
+	deadline := time.Now().Add(logEntryGracePeriod)
+	for {
+		lines, _ := capturer.stderrSince(mark)
+		for _, line := range lines {
+			if matchesLogEntry(line, expected) {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	expectedData, _ := json.Marshal(expected)
+	recent, _ := capturer.stderrSince(0)
+	if len(recent) > logEntryContextLines {
+		recent = recent[len(recent)-logEntryContextLines:]
+	}
+	if len(recent) == 0 {
+		return fmt.Errorf("expected log entry %s not found: handler wrote nothing to stderr", expectedData)
+	}
+	return fmt.Errorf("expected log entry %s not found in stderr, most recent lines:\n  %s",
+		expectedData, strings.Join(recent, "\n  "))
+}
+
+// matchesLogEntry reports whether line is a JSON object containing all key-value pairs
+// of expected
+func matchesLogEntry(line string, expected map[string]any) bool {
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return false
+	}
+	for key, want := range expected {
+		got, ok := entry[key]
+		if !ok || !reflect.DeepEqual(got, want) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunTest_ExpectedLogEntry(t *testing.T) {
+	tests := []struct {
+		name        string
+		entry       string
+		wantPassed  bool
+		wantMessage string
+	}{
+		{
+			name:       "subset matches",
+			entry:      `{"level": "info", "method": "log"}`,
+			wantPassed: true,
+		},
+		{
+			name:        "value differs",
+			entry:       `{"level": "error", "method": "log"}`,
+			wantMessage: `"msg":"handled request"`,
+		},
+		{
+			name:        "key missing",
+			entry:       `{"block_height": 1}`,
+			wantMessage: "most recent lines",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := newHandlerForTest(t, helperNameLogging, 0)
+			if err != nil {
+				t.Fatalf("Failed to create handler: %v", err)
+			}
+			tr := &TestRunner{handler: h}
+			defer tr.CloseHandler()
+
+			test := TestCase{
+				Request:          Request{ID: "1", Method: "log"},
+				ExpectedResponse: Response{Result: Result(`true`)},
+			}
+			if err := json.Unmarshal([]byte(tt.entry), &test.ExpectedLogEntry); err != nil {
+				t.Fatalf("invalid expected entry: %v", err)
+			}
+
+			result := tr.runTest(context.Background(), &test)
+			if result.Passed != tt.wantPassed {
+				t.Fatalf("expected passed=%v, got %v (%s)", tt.wantPassed, result.Passed, result.Message)
+			}
+			if !strings.Contains(result.Message, tt.wantMessage) {
+				t.Errorf("expected message to contain %q, got %q", tt.wantMessage, result.Message)
+			}
+		})
+	}
+}
+
+func TestRunTest_ExpectedLogEntryInProcess(t *testing.T) {
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		return Response{Result: Result(`true`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+
+	test := TestCase{
+		Request:          Request{ID: "1", Method: "log"},
+		ExpectedResponse: Response{Result: Result(`true`)},
+		ExpectedLogEntry: map[string]any{"level": "info"},
+	}
+	result := tr.runTest(context.Background(), &test)
+	if result.Passed || !strings.Contains(result.Message, "does not capture stderr") {
+		t.Errorf("expected failure for handler without stderr, got %+v", result)
+	}
+}
+
+func TestRunTestSuite_HandlerStderr(t *testing.T) {
+	h, err := newHandlerForTest(t, helperNameLogging, 0)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	tr := &TestRunner{handler: h}
+	defer tr.CloseHandler()
+
+	// The expected log entries make sure each test's stderr output has been read
+	suite := TestSuite{
+		Name: "Stderr",
+		Tests: []TestCase{
+			{
+				Request:          Request{ID: "1", Method: "first"},
+				ExpectedResponse: Response{Result: Result(`true`)},
+				ExpectedLogEntry: map[string]any{"method": "first"},
+			},
+			{
+				Request:          Request{ID: "2", Method: "second"},
+				ExpectedResponse: Response{Result: Result(`true`)},
+				ExpectedLogEntry: map[string]any{"method": "second"},
+			},
+		},
+	}
+
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{Verbosity: VerbosityAlways})
+	for i, method := range []string{"first", "second"} {
+		got := result.TestResults[i]
+		if !got.Passed {
+			t.Fatalf("test %s failed: %s", got.TestID, got.Message)
+		}
+		want := fmt.Sprintf("starting %s\n"+`{"level":"info","msg":"handled request","method":%q}`, method, method)
+		if got.HandlerStderr != want {
+			t.Errorf("test %s: expected stderr %q, got %q", got.TestID, want, got.HandlerStderr)
+		}
+		if !strings.Contains(got.Message, "Handler stderr") {
+			t.Errorf("test %s: expected verbose output to include stderr, got %q", got.TestID, got.Message)
+		}
+	}
+
+	result = tr.RunTestSuite(context.Background(), suite, RunOptions{Verbosity: VerbosityOnFailure})
+	for _, got := range result.TestResults {
+		if got.HandlerStderr != "" {
+			t.Errorf("test %s: expected no stderr without verbose output, got %q", got.TestID, got.HandlerStderr)
+		}
+	}
+}
+
+// helperLogging simulates a handler with JSON-structured stderr logging. It logs a
+// non-JSON line and an entry for every request before responding with true.
+func helperLogging() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req Request
+		json.Unmarshal(scanner.Bytes(), &req)
+		fmt.Fprintf(os.Stderr, "starting %s\n", req.Method)
+		fmt.Fprintf(os.Stderr, `{"level":"info","msg":"handled request","method":%q}`+"\n", req.Method)
+		fmt.Println(`{"result":true}`)
+	}
+}
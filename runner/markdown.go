@@ -0,0 +1,126 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TestResults is a list of suite results, as produced by running several test suites
+type TestResults []TestResult
+
+// ToMarkdown formats the suite result as GitHub-Flavored Markdown, suitable for a pull
+// request comment. Failed, disabled, and skipped tests are listed in a table; passed
+// tests are collapsed into a <details> block below it.
+func (r TestResult) ToMarkdown() string {
+	var b strings.Builder
+
+	status := "✅"
+	if r.FailedTests > 0 {
+		status = "❌"
+	}
+	fmt.Fprintf(&b, "### %s %s\n\n", status, r.SuiteName)
+
+	if r.Skipped {
+		fmt.Fprintf(&b, "Suite skipped: %s\n", markdownEscape(r.SkipReason))
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "%d/%d passed", r.PassedTests, r.TotalTests)
+	if r.DisabledTests > 0 {
+		fmt.Fprintf(&b, ", %d disabled", r.DisabledTests)
+	}
+	if r.SkippedTests > 0 {
+		fmt.Fprintf(&b, ", %d skipped", r.SkippedTests)
+	}
+	b.WriteString("\n\n")
+
+	var failed, disabled, passed []SingleTestResult
+	for _, tr := range r.TestResults {
+		switch {
+		case tr.Disabled || tr.Skipped:
+			disabled = append(disabled, tr)
+		case tr.Passed:
+			passed = append(passed, tr)
+		default:
+			failed = append(failed, tr)
+		}
+	}
+
+	if len(failed)+len(disabled) > 0 {
+		writeMarkdownTable(&b, r.SuiteName, append(failed, disabled...))
+	}
+	if len(passed) > 0 {
+		fmt.Fprintf(&b, "<details><summary>%d passed</summary>\n\n", len(passed))
+		writeMarkdownTable(&b, r.SuiteName, passed)
+		b.WriteString("</details>\n\n")
+	}
+
+	return b.String()
+}
+
+// SummaryMarkdown formats the results of several suites as GitHub-Flavored Markdown,
+// starting with the overall totals followed by each suite's ToMarkdown output
+func (results TestResults) SummaryMarkdown() string {
+	var b strings.Builder
+
+	var total, passed, failed, disabled, skipped int
+	for _, r := range results {
+		total += r.TotalTests
+		passed += r.PassedTests
+		failed += r.FailedTests
+		disabled += r.DisabledTests
+		skipped += r.SkippedTests
+	}
+
+	status := "✅"
+	if failed > 0 {
+		status = "❌"
+	}
+	fmt.Fprintf(&b, "## %s Conformance Test Results\n\n", status)
+	fmt.Fprintf(&b, "**Total:** %d, **Passed:** %d, **Failed:** %d", total, passed, failed)
+	if disabled > 0 {
+		fmt.Fprintf(&b, ", **Disabled:** %d", disabled)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(&b, ", **Skipped:** %d", skipped)
+	}
+	b.WriteString("\n\n")
+
+	for _, r := range results {
+		b.WriteString(r.ToMarkdown())
+	}
+
+	return b.String()
+}
+
+// writeMarkdownTable writes one table row per test result. Results merged from several
+// suites are prefixed with their own suite name, as in the text output.
+func writeMarkdownTable(b *strings.Builder, suiteName string, results []SingleTestResult) {
+	b.WriteString("| Status | Test ID | Description | Message |\n")
+	b.WriteString("| --- | --- | --- | --- |\n")
+	for _, tr := range results {
+		status := "✅"
+		switch {
+		case tr.Disabled:
+			status = "⏸️"
+		case tr.Skipped:
+			status = "⏭️"
+		case !tr.Passed:
+			status = "❌"
+		}
+
+		testID := tr.TestID
+		if tr.SuiteName != "" && tr.SuiteName != suiteName {
+			testID = tr.SuiteName + "::" + tr.TestID
+		}
+
+		fmt.Fprintf(b, "| %s | `%s` | %s | %s |\n", status, testID,
+			markdownEscape(tr.Description), markdownEscape(tr.Message))
+	}
+	b.WriteString("\n")
+}
+
+// markdownEscape makes s safe to place inside a table cell
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>").Replace(s)
+}
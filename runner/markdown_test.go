@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTestResult_ToMarkdown(t *testing.T) {
+	result := TestResult{
+		SuiteName:     "Suite",
+		TotalTests:    3,
+		PassedTests:   2,
+		FailedTests:   1,
+		DisabledTests: 1,
+		TestResults: []SingleTestResult{
+			{SuiteName: "Suite", TestID: "ok1", Description: "first", Passed: true},
+			{SuiteName: "Suite", TestID: "bad", Description: "a | b", Message: "line1\nline2"},
+			{SuiteName: "Suite", TestID: "off", Disabled: true},
+			{SuiteName: "Suite", TestID: "ok2", Passed: true},
+		},
+	}
+
+	md := result.ToMarkdown()
+
+	if !strings.HasPrefix(md, "### ❌ Suite\n") {
+		t.Errorf("expected failing suite heading, got:\n%s", md)
+	}
+	if !strings.Contains(md, "| ❌ | `bad` | a \\| b | line1<br>line2 |") {
+		t.Errorf("expected escaped failed row, got:\n%s", md)
+	}
+
+	// Failed tests come first, then disabled tests, then the collapsed passed tests
+	bad := strings.Index(md, "`bad`")
+	off := strings.Index(md, "`off`")
+	details := strings.Index(md, "<details><summary>2 passed</summary>")
+	ok1 := strings.Index(md, "`ok1`")
+	if bad < 0 || off < 0 || details < 0 || ok1 < 0 || !(bad < off && off < details && details < ok1) {
+		t.Errorf("unexpected row order, got:\n%s", md)
+	}
+}
+
+func TestTestResult_ToMarkdown_Skipped(t *testing.T) {
+	result := TestResult{SuiteName: "Suite", Skipped: true, SkipReason: "handler too old"}
+
+	md := result.ToMarkdown()
+
+	if !strings.Contains(md, "Suite skipped: handler too old") {
+		t.Errorf("expected skip reason, got:\n%s", md)
+	}
+	if strings.Contains(md, "| Status |") {
+		t.Errorf("expected no table for skipped suite, got:\n%s", md)
+	}
+}
+
+func TestTestResults_SummaryMarkdown(t *testing.T) {
+	results := TestResults{
+		{SuiteName: "A", TotalTests: 1, PassedTests: 1, TestResults: []SingleTestResult{{SuiteName: "A", TestID: "a1", Passed: true}}},
+		{SuiteName: "B", TotalTests: 1, FailedTests: 1, TestResults: []SingleTestResult{{SuiteName: "B", TestID: "b1"}}},
+	}
+
+	md := results.SummaryMarkdown()
+
+	if !strings.HasPrefix(md, "## ❌ Conformance Test Results\n") {
+		t.Errorf("expected failing summary heading, got:\n%s", md)
+	}
+	if !strings.Contains(md, "**Total:** 2, **Passed:** 1, **Failed:** 1") {
+		t.Errorf("expected totals, got:\n%s", md)
+	}
+	if !strings.Contains(md, "### ✅ A") || !strings.Contains(md, "### ❌ B") {
+		t.Errorf("expected a section per suite, got:\n%s", md)
+	}
+}
@@ -0,0 +1,16 @@
+package runner
+
+// SetProgress sets a function that RunTestSuite calls with the result of every test,
+// including disabled ones, as soon as the test completes, so that long suites can
+// report progress before they finish. It is called on the goroutine running the suite.
+// A nil function disables progress reporting.
+func (tr *TestRunner) SetProgress(progress func(SingleTestResult)) {
+	tr.progress = progress
+}
+
+// reportProgress passes a test result to the progress function, if any
+func (tr *TestRunner) reportProgress(result SingleTestResult) {
+	if tr.progress != nil {
+		tr.progress(result)
+	}
+}
@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRunTestSuite_Progress(t *testing.T) {
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		return Response{Result: Result(`true`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	var events []string
+	tr.SetProgress(func(result SingleTestResult) {
+		events = append(events, "done "+result.TestID)
+	})
+	start := func(id string) func(context.Context) error {
+		return func(context.Context) error {
+			events = append(events, "start "+id)
+			return nil
+		}
+	}
+
+	suite := TestSuite{
+		Name: "Progress",
+		Tests: []TestCase{
+			{Request: Request{ID: "1", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}, BeforeHook: start("1")},
+			{Request: Request{ID: "2", Method: "m"}, Disabled: true},
+			{Request: Request{ID: "3", Method: "m"}, ExpectedResponse: Response{Result: Result(`false`)}, BeforeHook: start("3")},
+		},
+	}
+
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+
+	// Every test is reported before the next one starts
+	want := []string{"start 1", "done 1", "done 2", "start 3", "done 3"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+	if len(result.TestResults) != 3 {
+		t.Errorf("expected the suite result to keep all test results, got %d", len(result.TestResults))
+	}
+}
@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+)
+
+// RecordSuite sends each request to the handler in order and builds a test suite whose
+// expected responses are the responses actually received. This supports workflows where
+// a suite is recorded against a known-good handler and replayed against a new one.
+// The recorded suite is marked stateful if any request creates a reference, since later
+// requests may then depend on earlier ones.
+func (tr *TestRunner) RecordSuite(ctx context.Context, requests []Request, name string) (*TestSuite, error) {
+	suite := &TestSuite{
+		Name:  name,
+		Tests: make([]TestCase, 0, len(requests)),
+	}
+
+	for _, req := range requests {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("recording aborted before request %s: %w", req.ID, err)
+		}
+
+		if err := tr.SendRequest(req); err != nil {
+			return nil, fmt.Errorf("request %s: %w", req.ID, err)
+		}
+		resp, err := tr.ReadResponse()
+		if err != nil {
+			return nil, fmt.Errorf("request %s: failed to read response: %w", req.ID, err)
+		}
+
+		suite.Tests = append(suite.Tests, TestCase{
+			Request:          req,
+			ExpectedResponse: *resp,
+		})
+		if req.Ref != "" {
+			suite.Stateful = true
+		}
+	}
+
+	return suite, nil
+}
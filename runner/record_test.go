@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestRecordSuite(t *testing.T) {
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		switch req.Method {
+		case "create":
+			result, _ := json.Marshal(RefObject{Ref: req.Ref})
+			return Response{Result: result}
+		case "get":
+			return Response{Result: Result(`42`)}
+		default:
+			return Response{Error: &Error{Code: &ErrorCode{Type: "Handler", Member: "UNKNOWN_METHOD"}}}
+		}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	requests := []Request{
+		{ID: "1", Method: "create", Ref: "$obj"},
+		{ID: "2", Method: "get", Params: json.RawMessage(`{"obj": {"ref": "$obj"}}`)},
+		{ID: "3", Method: "bogus"},
+	}
+
+	suite, err := tr.RecordSuite(context.Background(), requests, "recorded")
+	if err != nil {
+		t.Fatalf("RecordSuite failed: %v", err)
+	}
+	if suite.Name != "recorded" || len(suite.Tests) != 3 {
+		t.Fatalf("unexpected suite: name=%q tests=%d", suite.Name, len(suite.Tests))
+	}
+	if !suite.Stateful {
+		t.Errorf("expected suite with refs to be stateful")
+	}
+	if string(suite.Tests[1].ExpectedResponse.Result) != `42` {
+		t.Errorf("expected recorded result 42, got %s", suite.Tests[1].ExpectedResponse.Result)
+	}
+	if suite.Tests[2].ExpectedResponse.Error == nil {
+		t.Errorf("expected recorded error response for test 3")
+	}
+
+	// Replaying the recorded suite against the same handler must pass
+	result := tr.RunTestSuite(context.Background(), *suite, RunOptions{})
+	if result.FailedTests != 0 {
+		t.Errorf("expected replay to pass, got %d failures: %+v", result.FailedTests, result.TestResults)
+	}
+}
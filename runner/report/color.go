@@ -0,0 +1,41 @@
+package report
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// Color is an ANSI foreground color
+type Color string
+
+const (
+	ColorGreen Color = "32"
+	ColorRed   Color = "31"
+)
+
+// ColorEnabled reports whether ANSI colors should be written to f: f is a terminal,
+// TERM is set and not "dumb", and the NO_COLOR environment variable is unset or empty
+// (see https://no-color.org). Formatters whose output is not meant for a terminal should
+// never use colors, whatever this returns.
+func ColorEnabled(f *os.File) bool {
+	return envAllowsColor() && term.IsTerminal(int(f.Fd()))
+}
+
+// envAllowsColor reports whether the environment allows colors, following ColorEnabled
+func envAllowsColor() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	t := os.Getenv("TERM")
+	return t != "" && t != "dumb"
+}
+
+// Colorize wraps s in the escape codes for color if enabled, and returns s unchanged
+// otherwise
+func Colorize(s string, color Color, enabled bool) string {
+	if !enabled {
+		return s
+	}
+	return "\x1b[" + string(color) + "m" + s + "\x1b[0m"
+}
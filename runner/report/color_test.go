@@ -0,0 +1,49 @@
+package report
+
+import (
+	"os"
+	"testing"
+)
+
+func TestColorize(t *testing.T) {
+	if got := Colorize("✓ 1", ColorGreen, true); got != "\x1b[32m✓ 1\x1b[0m" {
+		t.Errorf("Colorize enabled = %q", got)
+	}
+	if got := Colorize("✗ 1", ColorRed, false); got != "✗ 1" {
+		t.Errorf("Colorize disabled = %q", got)
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	t.Setenv("TERM", "xterm-256color")
+	if ColorEnabled(f) {
+		t.Errorf("expected colors to be disabled for a regular file")
+	}
+}
+
+func TestEnvAllowsColor(t *testing.T) {
+	tests := []struct {
+		noColor string
+		term    string
+		want    bool
+	}{
+		{"", "xterm-256color", true},
+		{"1", "xterm-256color", false},
+		{"", "dumb", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		// An empty NO_COLOR does not disable colors
+		t.Setenv("NO_COLOR", tt.noColor)
+		t.Setenv("TERM", tt.term)
+		if got := envAllowsColor(); got != tt.want {
+			t.Errorf("envAllowsColor() with NO_COLOR=%q TERM=%q = %v, want %v", tt.noColor, tt.term, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package report writes test results in formats consumed by external tools, such as
+// the JUnit XML reports understood by most CI systems.
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite is the report of a single TestResult
+type junitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	TestCases  []junitTestCase  `xml:"testcase"`
+}
+
+// junitProperties holds the properties of a test suite
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+// junitProperty is a name-value pair attached to a test suite
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// junitTestCase is the report of a single SingleTestResult
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+// junitMessage is a failure or skip marker of a test case
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the results as a JUnit XML report with one <testsuite> per suite
+// result and one <testcase> per test result. Failed tests carry a <failure> with the
+// test's message and disabled and skipped tests are reported as skipped. The classname of
+// a test case is "<suite>.<test id>". Suite metadata and the reason a suite was skipped
+// are written as suite properties.
+func WriteJUnit(w io.Writer, results []runner.TestResult) error {
+	report := junitTestSuites{}
+	var total time.Duration
+	for _, result := range results {
+		suite := junitTestSuite{
+			Name:     result.SuiteName,
+			Tests:    result.TotalTests + result.DisabledTests + result.SkippedTests,
+			Failures: result.FailedTests,
+			Skipped:  result.DisabledTests + result.SkippedTests,
+			Time:     junitTime(result.Duration),
+		}
+		var properties []junitProperty
+		for _, name := range slices.Sorted(maps.Keys(result.SuiteMetadata)) {
+			properties = append(properties, junitProperty{Name: name, Value: result.SuiteMetadata[name]})
+		}
+		if result.Skipped {
+			properties = append(properties, junitProperty{Name: "skip_reason", Value: result.SkipReason})
+		}
+		if properties != nil {
+			suite.Properties = &junitProperties{Properties: properties}
+		}
+
+		for _, tr := range result.TestResults {
+			suiteName := tr.SuiteName
+			if suiteName == "" {
+				suiteName = result.SuiteName
+			}
+			testCase := junitTestCase{
+				Name:      tr.TestID,
+				ClassName: suiteName + "." + tr.TestID,
+				Time:      junitTime(tr.Duration),
+			}
+			switch {
+			case tr.Disabled:
+				testCase.Skipped = &junitMessage{Message: "disabled"}
+			case tr.Skipped:
+				testCase.Skipped = &junitMessage{Message: tr.Message}
+			case !tr.Passed:
+				testCase.Failure = &junitMessage{Message: tr.Message, Text: tr.Message}
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+
+		report.Suites = append(report.Suites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		total += result.Duration
+	}
+	report.Time = junitTime(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitTime formats a duration in seconds, as expected by the time attributes
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
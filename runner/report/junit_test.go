@@ -0,0 +1,72 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	results := []runner.TestResult{
+		{
+			SuiteName:     "Chain",
+			SuiteMetadata: map[string]string{"spec_section": "4.2"},
+			TotalTests:    2,
+			PassedTests:   1,
+			FailedTests:   1,
+			DisabledTests: 1,
+			Duration:      1500 * time.Millisecond,
+			TestResults: []runner.SingleTestResult{
+				{SuiteName: "Chain", TestID: "chain#1", Passed: true, Duration: 250 * time.Millisecond},
+				{SuiteName: "Chain", TestID: "chain#2", Message: `result mismatch: expected 1, got "<2>"`},
+				{SuiteName: "Chain", TestID: "chain#3", Disabled: true},
+			},
+		},
+		{SuiteName: "Mainnet", Skipped: true, SkipReason: "handler version 1.0.0 does not satisfy >=2.0.0"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, results); err != nil {
+		t.Fatalf("WriteJUnit failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Errorf("expected XML header, got:\n%s", buf.String())
+	}
+
+	var got junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("report is not well-formed XML: %v\n%s", err, buf.String())
+	}
+	if got.Tests != 3 || got.Failures != 1 || got.Skipped != 1 || got.Time != "1.500" {
+		t.Errorf("unexpected totals: %+v", got)
+	}
+	if len(got.Suites) != 2 {
+		t.Fatalf("expected 2 test suites, got %d", len(got.Suites))
+	}
+
+	chain := got.Suites[0]
+	if chain.Properties == nil || len(chain.Properties.Properties) != 1 || chain.Properties.Properties[0] != (junitProperty{Name: "spec_section", Value: "4.2"}) {
+		t.Errorf("unexpected properties: %+v", chain.Properties)
+	}
+	if len(chain.TestCases) != 3 {
+		t.Fatalf("expected 3 test cases, got %d", len(chain.TestCases))
+	}
+	if tc := chain.TestCases[0]; tc.Name != "chain#1" || tc.ClassName != "Chain.chain#1" || tc.Time != "0.250" || tc.Failure != nil || tc.Skipped != nil {
+		t.Errorf("unexpected passed test case: %+v", tc)
+	}
+	if tc := chain.TestCases[1]; tc.Failure == nil || tc.Failure.Message != `result mismatch: expected 1, got "<2>"` {
+		t.Errorf("unexpected failed test case: %+v", tc)
+	}
+	if tc := chain.TestCases[2]; tc.Skipped == nil || tc.Skipped.Message != "disabled" {
+		t.Errorf("unexpected disabled test case: %+v", tc)
+	}
+
+	mainnet := got.Suites[1]
+	if len(mainnet.TestCases) != 0 || mainnet.Properties == nil || len(mainnet.Properties.Properties) != 1 || mainnet.Properties.Properties[0].Name != "skip_reason" {
+		t.Errorf("unexpected skipped suite: %+v", mainnet)
+	}
+}
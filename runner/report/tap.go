@@ -0,0 +1,65 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// WriteTAP writes the results as a TAP version 13 stream with one test point per test
+// result. Failed tests carry their message in a YAML diagnostic block, disabled and
+// skipped tests are marked with a SKIP directive, the latter with their skip reason, and
+// skipped suites are reported as comments.
+func WriteTAP(w io.Writer, results []runner.TestResult) error {
+	var b strings.Builder
+	b.WriteString("TAP version 13\n")
+
+	total := 0
+	for _, result := range results {
+		total += len(result.TestResults)
+	}
+	fmt.Fprintf(&b, "1..%d\n", total)
+
+	n := 0
+	for _, result := range results {
+		if result.Skipped {
+			fmt.Fprintf(&b, "# Suite %s skipped: %s\n", result.SuiteName, result.SkipReason)
+			continue
+		}
+		for _, tr := range result.TestResults {
+			n++
+			suiteName := tr.SuiteName
+			if suiteName == "" {
+				suiteName = result.SuiteName
+			}
+			label := suiteName + ": " + tr.TestID
+			if tr.Description != "" {
+				label += " (" + tr.Description + ")"
+			}
+			// An unescaped # would start a directive
+			label = strings.ReplaceAll(label, "#", `\#`)
+
+			switch {
+			case tr.Disabled:
+				fmt.Fprintf(&b, "ok %d - %s # SKIP disabled\n", n, label)
+			case tr.Skipped:
+				// The reason must stay on the test point's line
+				reason := strings.Join(strings.Fields(tr.Message), " ")
+				fmt.Fprintf(&b, "ok %d - %s\n", n, strings.TrimSpace(label+" # SKIP "+reason))
+			case tr.Passed:
+				fmt.Fprintf(&b, "ok %d - %s\n", n, label)
+			default:
+				fmt.Fprintf(&b, "not ok %d - %s\n", n, label)
+				// A JSON string is a valid YAML double-quoted scalar
+				message, _ := json.Marshal(tr.Message)
+				fmt.Fprintf(&b, "  ---\n  message: %s\n  ...\n", message)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,45 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+func TestWriteTAP(t *testing.T) {
+	results := []runner.TestResult{
+		{
+			SuiteName: "Chain",
+			TestResults: []runner.SingleTestResult{
+				{SuiteName: "Chain", TestID: "chain#1", Description: "Create context", Passed: true},
+				{SuiteName: "Chain", TestID: "chain#2", Message: "result mismatch: expected 1, got 2\nsecond line"},
+				{SuiteName: "Chain", TestID: "chain#3", Disabled: true},
+				{SuiteName: "Chain", TestID: "chain#4", Skipped: true, Message: "mainnet\nonly"},
+				{SuiteName: "Chain", TestID: "chain#5", Skipped: true},
+			},
+		},
+		{SuiteName: "Mainnet", Skipped: true, SkipReason: "version mismatch"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTAP(&buf, results); err != nil {
+		t.Fatalf("WriteTAP failed: %v", err)
+	}
+
+	want := `TAP version 13
+1..5
+ok 1 - Chain: chain\#1 (Create context)
+not ok 2 - Chain: chain\#2
+  ---
+  message: "result mismatch: expected 1, got 2\nsecond line"
+  ...
+ok 3 - Chain: chain\#3 # SKIP disabled
+ok 4 - Chain: chain\#4 # SKIP mainnet only
+ok 5 - Chain: chain\#5 # SKIP
+# Suite Mainnet skipped: version mismatch
+`
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected TAP output:\n%s\nwant:\n%s", got, want)
+	}
+}
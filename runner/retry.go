@@ -0,0 +1,86 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// retryBackoff is the delay before the first retry of a failed test or suite; each
+// further retry waits one more multiple of it
+const retryBackoff = 100 * time.Millisecond
+
+// runTestWithRetries runs a test, running it again up to retryCount times while it
+// fails. The result of the last attempt is returned, with Retries set to the number of
+// failed attempts before it. Waiting between attempts stops when ctx is done.
+func (tr *TestRunner) runTestWithRetries(ctx context.Context, suite *TestSuite, test *TestCase, retryCount int) SingleTestResult {
+	for attempt := 0; ; attempt++ {
+		testStart := time.Now()
+		result := tr.runTest(ctx, test)
+		result.Duration = time.Since(testStart)
+		result.Retries = attempt
+		if result.Passed || attempt >= retryCount {
+			return result
+		}
+
+		backoff := retryBackoff * time.Duration(attempt+1)
+		tr.log().Warn("Test failed, retrying", "suite", suite.Name, "test", test.Request.ID,
+			"attempt", attempt+1, "retry_count", retryCount, "backoff", backoff, "error", result.Message)
+		if !waitBackoff(ctx, backoff) {
+			return result
+		}
+	}
+}
+
+// runSuiteWithRetries runs a stateful suite like runSuiteAttempt, running it again from
+// the beginning with a fresh handler up to opts.Retries times while any of its tests
+// fail. Only the last attempt is recorded, and the Retries of each of its tests is
+// increased by the number of earlier attempts in which the test did not pass.
+// As earlier attempts are discarded, progress is only reported for the last one, once
+// it is known to be the last.
+func (tr *TestRunner) runSuiteWithRetries(ctx context.Context, suite *TestSuite, order []int, opts RunOptions, result *TestResult) {
+	progress := tr.progress
+	tr.progress = nil
+	defer func() { tr.progress = progress }()
+
+	initial := *result
+	notPassed := make(map[string]int)
+	for attempt := 0; ; attempt++ {
+		*result = initial
+		result.Retries = attempt
+		tr.runSuiteAttempt(ctx, suite, order, opts, result)
+		if (!result.Skipped && result.FailedTests == 0) || attempt >= opts.Retries {
+			break
+		}
+
+		for _, testResult := range result.TestResults {
+			if !testResult.Passed && !testResult.Disabled && !testResult.Skipped {
+				notPassed[testResult.TestID]++
+			}
+		}
+		backoff := retryBackoff * time.Duration(attempt+1)
+		tr.log().Warn("Stateful suite failed, retrying from the beginning", "suite", suite.Name,
+			"attempt", attempt+1, "retries", opts.Retries, "failed_tests", result.FailedTests, "backoff", backoff)
+		tr.restartHandler(fmt.Sprintf("retrying suite %s", suite.Name))
+		if !waitBackoff(ctx, backoff) {
+			break
+		}
+	}
+
+	for i := range result.TestResults {
+		result.TestResults[i].Retries += notPassed[result.TestResults[i].TestID]
+		if progress != nil {
+			progress(result.TestResults[i])
+		}
+	}
+}
+
+// waitBackoff waits for the given duration, returning false if ctx is done first
+func waitBackoff(ctx context.Context, backoff time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(backoff):
+		return true
+	}
+}
@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRunTestSuite_RetryCount(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int
+		retryCount  int
+		wantPassed  bool
+		wantRetries int
+		wantCalls   int
+	}{
+		{"passes without retry", 0, 2, true, 0, 1},
+		{"passes on retry", 2, 2, true, 2, 3},
+		{"retries exhausted", 3, 2, false, 2, 3},
+		{"no retries", 1, 0, false, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			tr, err := NewTestRunnerInProcess(func(req Request) Response {
+				calls++
+				if calls <= tt.failures {
+					return Response{Error: &Error{}}
+				}
+				return Response{Result: Result(`true`)}
+			}, 0)
+			if err != nil {
+				t.Fatalf("failed to create in-process runner: %v", err)
+			}
+			defer tr.CloseHandler()
+			var logs bytes.Buffer
+			tr.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+
+			suite := TestSuite{
+				Name: "Retry",
+				Tests: []TestCase{{
+					Request:          Request{ID: "1", Method: "flaky"},
+					ExpectedResponse: Response{Result: Result(`true`)},
+					RetryCount:       tt.retryCount,
+				}},
+			}
+			result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+
+			got := result.TestResults[0]
+			if got.Passed != tt.wantPassed || got.Retries != tt.wantRetries || calls != tt.wantCalls {
+				t.Errorf("expected passed=%v retries=%d calls=%d, got passed=%v retries=%d calls=%d",
+					tt.wantPassed, tt.wantRetries, tt.wantCalls, got.Passed, got.Retries, calls)
+			}
+			if tt.wantPassed && result.FailedTests != 0 {
+				t.Errorf("expected no failed tests, got %d", result.FailedTests)
+			}
+			if warnings := strings.Count(logs.String(), "Test failed, retrying"); warnings != tt.wantCalls-1 {
+				t.Errorf("expected %d retry warnings, got %d:\n%s", tt.wantCalls-1, warnings, logs.String())
+			}
+		})
+	}
+}
+
+func TestRunTestSuite_Retries(t *testing.T) {
+	t.Run("stateless suite retries failed tests", func(t *testing.T) {
+		calls := make(map[string]int)
+		tr, err := NewTestRunnerInProcess(func(req Request) Response {
+			calls[req.ID]++
+			if req.ID == "flaky" && calls[req.ID] == 1 {
+				return Response{Error: &Error{}}
+			}
+			return Response{Result: Result(`true`)}
+		}, 0)
+		if err != nil {
+			t.Fatalf("failed to create in-process runner: %v", err)
+		}
+		defer tr.CloseHandler()
+
+		suite := TestSuite{
+			Name: "Stateless",
+			Tests: []TestCase{
+				{Request: Request{ID: "stable", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+				{Request: Request{ID: "flaky", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+			},
+		}
+		result := tr.RunTestSuite(context.Background(), suite, RunOptions{Retries: 1})
+
+		if result.FailedTests != 0 || result.Retries != 0 {
+			t.Errorf("expected all tests to pass without rerunning the suite, got %+v", result)
+		}
+		if calls["stable"] != 1 || calls["flaky"] != 2 {
+			t.Errorf("expected only the flaky test to be run again, got calls %v", calls)
+		}
+		if result.TestResults[0].Retries != 0 || result.TestResults[1].Retries != 1 {
+			t.Errorf("expected only the flaky test to be retried, got %+v", result.TestResults)
+		}
+	})
+
+	t.Run("stateful suite is run again from the beginning", func(t *testing.T) {
+		var requests []string
+		handlers := 0
+		tr, err := NewTestRunnerWithHandler(func() (HandlerInterface, error) {
+			handlers++
+			return &inProcessHandler{fn: func(req Request) Response {
+				requests = append(requests, req.ID)
+				if req.ID == "2" && handlers == 1 {
+					return Response{Error: &Error{}}
+				}
+				return Response{Result: Result(`true`)}
+			}}, nil
+		}, 0)
+		if err != nil {
+			t.Fatalf("failed to create runner: %v", err)
+		}
+		defer tr.CloseHandler()
+		var progress []SingleTestResult
+		tr.SetProgress(func(r SingleTestResult) { progress = append(progress, r) })
+
+		suite := TestSuite{
+			Name:     "Stateful",
+			Stateful: true,
+			Tests: []TestCase{
+				{Request: Request{ID: "1", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+				{Request: Request{ID: "2", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+			},
+		}
+		result := tr.RunTestSuite(context.Background(), suite, RunOptions{Retries: 2})
+
+		if result.FailedTests != 0 || result.Retries != 1 {
+			t.Errorf("expected the suite to pass on its first retry, got %+v", result)
+		}
+		if got := strings.Join(requests, ","); got != "1,2,1,2" || handlers != 2 {
+			t.Errorf("expected the suite to be run again with a fresh handler, got requests %s with %d handlers", got, handlers)
+		}
+		if result.TestResults[0].Retries != 0 || result.TestResults[1].Retries != 1 {
+			t.Errorf("expected only test 2 to be marked as retried, got %+v", result.TestResults)
+		}
+		if len(progress) != 2 || !progress[1].Passed {
+			t.Errorf("expected progress for the last attempt only, got %+v", progress)
+		}
+	})
+}
@@ -1,16 +1,19 @@
 package runner
 
 import (
+	"bytes"
 	"context"
-	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -26,11 +29,105 @@ const (
 	VerbosityAlways
 )
 
+// RunOptions controls a single run of a test suite
+type RunOptions struct {
+	// Verbosity selects the tests whose result message includes the request chain and
+	// the received and expected responses
+	Verbosity VerbosityLevel
+
+	// FailFast stops the suite after the first failed test. The remaining tests are
+	// neither run nor recorded in the result.
+	FailFast bool
+
+	// Retries is how many more times a failed test is run before it counts as failed,
+	// unless the test's own RetryCount is larger. As a failed test may leave invalid
+	// state behind, a stateful suite with failed tests is instead run again from the
+	// beginning with a fresh handler, up to Retries times.
+	Retries int
+}
+
+// RestartPolicy controls when the test runner replaces its handler with a fresh one.
+// Regardless of policy, a handler that crashed or timed out is always replaced, and
+// handlers are spawned lazily when the next request is sent.
+type RestartPolicy int
+
+const (
+	// RestartNever only replaces the handler when it can no longer be used
+	RestartNever RestartPolicy = iota
+	// RestartOnFailure additionally restarts the handler after any failed test in
+	// non-stateful suites
+	RestartOnFailure
+	// RestartAfterSuite restarts the handler after every test suite
+	RestartAfterSuite
+	// RestartAlways restarts the handler after every test in non-stateful suites.
+	// Stateful suites keep a single handler for the whole suite.
+	RestartAlways
+)
+
+var restartPolicyNames = map[RestartPolicy]string{
+	RestartNever:      "never",
+	RestartOnFailure:  "on-failure",
+	RestartAfterSuite: "after-suite",
+	RestartAlways:     "always",
+}
+
+// String returns the policy name as accepted by ParseRestartPolicy
+func (p RestartPolicy) String() string {
+	if name, ok := restartPolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("RestartPolicy(%d)", int(p))
+}
+
+// ParseRestartPolicy parses a restart policy name: never, on-failure, after-suite or always
+func ParseRestartPolicy(name string) (RestartPolicy, error) {
+	for policy, policyName := range restartPolicyNames {
+		if policyName == name {
+			return policy, nil
+		}
+	}
+	return RestartNever, fmt.Errorf("unknown restart policy %q (expected never, on-failure, after-suite or always)", name)
+}
+
 // TestRunner executes test suites against a handler binary
 type TestRunner struct {
-	handler       *Handler
+	handler       HandlerInterface
 	handlerConfig *HandlerConfig
 	timeout       time.Duration
+	restartPolicy RestartPolicy
+
+	// handlerVersion caches the version reported by the current handler process
+	handlerVersion string
+
+	// suiteLog receives every request and response while a suite with a LogFile runs
+	suiteLog   *json.Encoder
+	appendLogs bool
+
+	// artifacts receives every request and response while a suite with an ArtifactDir
+	// runs
+	artifacts *suiteArtifacts
+
+	// logMu serializes request and response logging, which happen concurrently in
+	// streaming mode
+	logMu sync.Mutex
+
+	isolateGroups bool
+	enforceSLOs   bool
+	abortOnCancel bool
+
+	// progress is called with the result of every test as soon as it completes
+	progress func(SingleTestResult)
+
+	// handlerRestarts counts handlers closed by the restart policy or after a failed
+	// read or write
+	handlerRestarts int
+
+	// newHandler spawns a fresh handler. Used to replace the handler after it has been
+	// closed (e.g., after a crash or at the end of a stateful suite).
+	newHandler func() (HandlerInterface, error)
+
+	// logger receives the runner's diagnostics; nil means slog.Default()
+	logger *slog.Logger
 }
 
 // NewTestRunner creates a new test runner for executing test suites against a handler binary.
@@ -55,54 +152,363 @@ func NewTestRunner(handlerPath string, handlerTimeout time.Duration, timeout tim
 		timeout = 30 * time.Second
 	}
 
-	return &TestRunner{
+	tr := &TestRunner{
 		handler: handler,
 		handlerConfig: &HandlerConfig{
-			Path:    handlerPath,
-			Timeout: handlerTimeout,
+			Path:       handlerPath,
+			Timeout:    handlerTimeout,
+			EscapeHTML: true,
 		},
 		timeout: timeout,
+	}
+	tr.newHandler = func() (HandlerInterface, error) {
+		return NewHandler(tr.handlerConfig)
+	}
+	return tr, nil
+}
+
+// NewTestRunnerInProcess creates a test runner that executes test suites against a Go
+// function instead of a handler binary. Each request is passed to the handler function
+// synchronously, so no subprocess, pipe, or goroutine is involved. This allows the same
+// conformance suites to run inside `go test` against a Go-native handler implementation.
+// The timeout parameter has the same meaning as in NewTestRunner.
+func NewTestRunnerInProcess(handler func(req Request) Response, timeout time.Duration) (*TestRunner, error) {
+	if handler == nil {
+		return nil, fmt.Errorf("handler function must not be nil")
+	}
+
+	return NewTestRunnerWithHandler(func() (HandlerInterface, error) {
+		return &inProcessHandler{fn: handler}, nil
+	}, timeout)
+}
+
+// NewTestRunnerWithHandler creates a test runner that talks to handlers created by
+// newHandler, which is called once up front and again whenever the handler needs to be
+// replaced. This allows custom HandlerInterface implementations to be used in place of
+// a handler binary. The timeout parameter has the same meaning as in NewTestRunner.
+func NewTestRunnerWithHandler(newHandler func() (HandlerInterface, error), timeout time.Duration) (*TestRunner, error) {
+	if newHandler == nil {
+		return nil, fmt.Errorf("handler constructor must not be nil")
+	}
+
+	h, err := newHandler()
+	if err != nil {
+		return nil, err
+	}
+
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &TestRunner{
+		handler:    h,
+		timeout:    timeout,
+		newHandler: newHandler,
 	}, nil
 }
 
+// Clone returns a test runner with the same handler configuration and options as tr,
+// but without a handler: it spawns its own handler when it first sends a request. As
+// the clone shares no handler state with tr, both can run suites concurrently. The
+// progress function is not copied. For runners created by NewTestRunnerWithHandler,
+// the handler constructor is shared and must be safe for concurrent use.
+func (tr *TestRunner) Clone() *TestRunner {
+	return &TestRunner{
+		handlerConfig: tr.handlerConfig,
+		timeout:       tr.timeout,
+		restartPolicy: tr.restartPolicy,
+		appendLogs:    tr.appendLogs,
+		isolateGroups: tr.isolateGroups,
+		enforceSLOs:   tr.enforceSLOs,
+		abortOnCancel: tr.abortOnCancel,
+		newHandler:    tr.newHandler,
+		logger:        tr.logger,
+	}
+}
+
+// WithHandler returns a clone of tr (see Clone) that runs the handler binary at path
+// with args instead of tr's handler, keeping the other handler settings such as the
+// timeouts. The handler is spawned when the clone first sends a request, so a path
+// that does not exist fails the clone's tests.
+func (tr *TestRunner) WithHandler(path string, args []string) *TestRunner {
+	cfg := HandlerConfig{EscapeHTML: true}
+	if tr.handlerConfig != nil {
+		cfg = *tr.handlerConfig
+	}
+	cfg.Path = path
+	cfg.Args = args
+	return tr.cloneWithConfig(&cfg)
+}
+
+// WithHandlerEnv returns a clone of tr (see Clone) whose handler is started with env,
+// a list of "KEY=value" entries, in addition to its configured environment. As the
+// clone spawns its own handler, the variables do not affect tr's handler. Handlers
+// that do not run as a subprocess are not affected by env.
+func (tr *TestRunner) WithHandlerEnv(env []string) *TestRunner {
+	if tr.handlerConfig == nil || tr.handlerConfig.Path == "" {
+		return tr.Clone()
+	}
+	cfg := *tr.handlerConfig
+	cfg.Env = append(slices.Clone(cfg.Env), env...)
+	return tr.cloneWithConfig(&cfg)
+}
+
+// cloneWithConfig returns a clone of tr that spawns handlers configured by cfg
+func (tr *TestRunner) cloneWithConfig(cfg *HandlerConfig) *TestRunner {
+	clone := tr.Clone()
+	clone.handlerConfig = cfg
+	clone.newHandler = func() (HandlerInterface, error) {
+		return NewHandler(clone.handlerConfig)
+	}
+	return clone
+}
+
+// NewHandlerPool creates a pool of size handler processes configured like the runner's
+// handler, for use with AcquireHandler. Returns nil if the runner's handler does not run
+// as a subprocess, e.g. for in-process runners.
+func (tr *TestRunner) NewHandlerPool(size int) (*HandlerPool, error) {
+	if tr.handlerConfig == nil || tr.handlerConfig.Path == "" {
+		return nil, nil
+	}
+	return NewHandlerPool(tr.handlerConfig, size)
+}
+
+// AcquireHandler replaces the runner's handler with one acquired from pool, blocking
+// until one is available or ctx is done. The returned function gives the handler back
+// to the pool; if the runner has replaced it in the meantime (e.g., after a crash or by
+// its restart policy), the runner's current handler is closed instead. With a nil pool,
+// the runner keeps its handler.
+func (tr *TestRunner) AcquireHandler(ctx context.Context, pool *HandlerPool) (release func(), err error) {
+	if pool == nil {
+		return func() {}, nil
+	}
+	ph, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tr.CloseHandler()
+	tr.handler = ph.Handler
+	return func() {
+		if tr.handler == HandlerInterface(ph.Handler) {
+			tr.handler = nil
+			tr.handlerVersion = ""
+		} else {
+			tr.CloseHandler()
+		}
+		ph.Release()
+	}, nil
+}
+
+// SetLogger sets the logger for the runner's diagnostics and for the handler processes
+// it spawns, including the current one. Defaults to slog.Default().
+func (tr *TestRunner) SetLogger(logger *slog.Logger) {
+	tr.logger = logger
+	if tr.handlerConfig != nil {
+		tr.handlerConfig.Logger = logger
+	}
+	if h, ok := tr.handler.(*Handler); ok {
+		h.logger = tr.log()
+	}
+}
+
+// SetEscapeHTML controls whether requests escape <, >, and & in strings (see
+// HandlerConfig.EscapeHTML).
+func (tr *TestRunner) SetEscapeHTML(escape bool) {
+	if tr.handlerConfig == nil {
+		tr.handlerConfig = &HandlerConfig{}
+	}
+	tr.handlerConfig.EscapeHTML = escape
+}
+
+// SetHandlerCloseTimeout sets how long to wait for a handler to exit after its stdin was
+// closed before killing it, for the handler processes the runner spawns, including the
+// current one (see HandlerConfig.CloseTimeout).
+func (tr *TestRunner) SetHandlerCloseTimeout(timeout time.Duration) {
+	if tr.handlerConfig == nil {
+		tr.handlerConfig = &HandlerConfig{}
+	}
+	tr.handlerConfig.CloseTimeout = timeout
+	if h, ok := tr.handler.(*Handler); ok && timeout > 0 {
+		h.closeTimeout = timeout
+	}
+}
+
+// SetHandlerBufferSize sets the maximum size in bytes of a response line of handler
+// processes (see HandlerConfig.BufferSize), including the current one
+func (tr *TestRunner) SetHandlerBufferSize(size int) {
+	if tr.handlerConfig == nil {
+		tr.handlerConfig = &HandlerConfig{}
+	}
+	tr.handlerConfig.BufferSize = size
+	if h, ok := tr.handler.(*Handler); ok && size > 0 && size <= MaxLineSize {
+		h.maxLineSize = size
+	}
+}
+
+// SetHandlerReadySignal sets the stdout line that handler processes write once they are
+// ready to receive requests (see HandlerConfig.ReadySignal). As the current handler was
+// spawned without waiting for it, it is replaced by a fresh one when the next request
+// is sent.
+func (tr *TestRunner) SetHandlerReadySignal(signal string) {
+	if tr.handlerConfig == nil {
+		tr.handlerConfig = &HandlerConfig{}
+	}
+	tr.handlerConfig.ReadySignal = signal
+	if _, ok := tr.handler.(*Handler); ok && signal != "" {
+		tr.CloseHandler()
+	}
+}
+
+// log returns the logger for the runner's diagnostics
+func (tr *TestRunner) log() *slog.Logger {
+	if tr.logger == nil {
+		return slog.Default()
+	}
+	return tr.logger
+}
+
+// SetRestartPolicy sets when the handler is proactively restarted. Defaults to RestartNever.
+func (tr *TestRunner) SetRestartPolicy(policy RestartPolicy) {
+	tr.restartPolicy = policy
+}
+
+// restartHandler closes the current handler so that a fresh one is spawned when the
+// next request is sent
+func (tr *TestRunner) restartHandler(reason string) {
+	if tr.handler == nil {
+		return
+	}
+	tr.log().Debug("Restarting handler", "policy", tr.restartPolicy, "reason", reason)
+	tr.handlerRestarts++
+	tr.CloseHandler()
+}
+
 // SendRequest sends a request to the handler, spawning a new handler if needed
 func (tr *TestRunner) SendRequest(req Request) error {
-	if tr.handler == nil {
-		handler, err := NewHandler(tr.handlerConfig)
-		if err != nil {
-			return fmt.Errorf("failed to spawn new handler: %w", err)
-		}
-		tr.handler = handler
+	if err := tr.ensureHandler(); err != nil {
+		return err
 	}
 
-	reqData, err := json.Marshal(req)
+	reqData, err := marshalRequest(req, tr.handlerConfig == nil || tr.handlerConfig.EscapeHTML)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
+	tr.logRequest(req)
 	if err := tr.handler.SendLine(reqData); err != nil {
-		slog.Warn("Failed to write request, cleaning up handler (will spawn new one for remaining tests)", "error", err)
+		tr.log().Warn("Failed to write request, cleaning up handler (will spawn new one for remaining tests)", "error", err)
+		tr.handlerRestarts++
 		tr.CloseHandler()
 		return fmt.Errorf("failed to write request: %w", err)
 	}
 	return nil
 }
 
-// ReadResponse reads and unmarshals a response from the handler
-func (tr *TestRunner) ReadResponse() (*Response, error) {
-	line, err := tr.handler.ReadLine()
+// ensureHandler spawns a new handler if there is none, e.g. because the previous one
+// crashed or was closed by the restart policy
+func (tr *TestRunner) ensureHandler() error {
+	if tr.handler != nil {
+		return nil
+	}
+	handler, err := tr.newHandler()
 	if err != nil {
-		slog.Warn("Failed to read response, cleaning up handler (will spawn new one for remaining tests)", "error", err)
-		tr.CloseHandler()
+		return fmt.Errorf("failed to spawn new handler: %w", err)
+	}
+	tr.handler = handler
+	return nil
+}
+
+// marshalRequest encodes a request as a single line without the trailing newline,
+// optionally escaping HTML characters in strings
+func marshalRequest(req Request, escapeHTML bool) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(escapeHTML)
+	if err := enc.Encode(req); err != nil {
 		return nil, err
 	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
 
+// ReadResponse reads and unmarshals a response from the handler
+func (tr *TestRunner) ReadResponse() (*Response, error) {
 	var resp Response
-	if err := json.Unmarshal(line, &resp); err != nil {
-		return nil, err
+	var readErr, decodeErr error
+	if streamer, ok := tr.handler.(responseStreamer); ok {
+		readErr, decodeErr = decodeStreamedResponse(streamer, &resp)
+	} else {
+		var line []byte
+		if line, readErr = tr.handler.ReadLine(); readErr == nil {
+			decodeErr = json.Unmarshal(line, &resp)
+		}
+	}
+	if readErr != nil {
+		tr.log().Warn("Failed to read response, cleaning up handler (will spawn new one for remaining tests)", "error", readErr)
+		tr.handlerRestarts++
+		tr.CloseHandler()
+		return nil, readErr
 	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	tr.logResponse(&resp)
 	return &resp, nil
 }
 
+// responseStreamer is implemented by handlers that can stream a response line instead
+// of returning it as a whole, such as Handler
+type responseStreamer interface {
+	ReadLargeResponse(ctx context.Context) (io.ReadCloser, error)
+}
+
+// decodeStreamedResponse decodes the next response line of the handler into resp with a
+// json.Decoder reading from the handler's stdout, without first copying the line into a
+// separate buffer. The decoder itself still buffers the whole response, as
+// Response.Result is a json.RawMessage. Errors reading from the handler are returned as
+// readErr, while malformed responses are returned as decodeErr.
+func decodeStreamedResponse(streamer responseStreamer, resp *Response) (readErr, decodeErr error) {
+	r, err := streamer.ReadLargeResponse(context.Background())
+	if err != nil {
+		return err, nil
+	}
+	stream := &errRecordingReader{r: r}
+	defer func() {
+		// Discard the rest of a malformed line so that the next response stays aligned
+		if err := r.Close(); err != nil && readErr == nil {
+			readErr = err
+		}
+	}()
+
+	dec := json.NewDecoder(stream)
+	if err := dec.Decode(resp); err != nil {
+		if stream.err != nil {
+			return stream.err, nil
+		}
+		return nil, err
+	}
+	// Like json.Unmarshal, reject anything but whitespace after the response object
+	if _, err := dec.Token(); err != io.EOF {
+		if stream.err != nil {
+			return stream.err, nil
+		}
+		return nil, fmt.Errorf("invalid data after response object")
+	}
+	return nil, nil
+}
+
+// errRecordingReader wraps a reader and records the first error other than io.EOF
+type errRecordingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *errRecordingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
 // CloseHandler closes the handler and sets it to nil
 func (tr *TestRunner) CloseHandler() {
 	if tr.handler == nil {
@@ -110,25 +516,245 @@ func (tr *TestRunner) CloseHandler() {
 	}
 	tr.handler.Close()
 	tr.handler = nil
+	tr.handlerVersion = ""
 }
 
 // RunTestSuite executes a test suite. The context can be used to enforce a total
-// execution timeout across all test suites.
-// The verbosity parameter controls output detail.
-func (tr *TestRunner) RunTestSuite(ctx context.Context, suite TestSuite, verbosity VerbosityLevel) TestResult {
+// execution timeout across all test suites; the suite's own TimeoutSeconds applies
+// on top of it.
+// The options control output detail and whether to stop at the first failure.
+func (tr *TestRunner) RunTestSuite(ctx context.Context, suite TestSuite, opts RunOptions) TestResult {
+	start := time.Now()
+	restartsBefore := tr.handlerRestarts
+
+	result := TestResult{
+		SuiteName:     suite.Name,
+		SuiteMetadata: suite.Metadata,
+	}
+
+	if suite.LogFile != "" {
+		closeLog, err := tr.openSuiteLog(suite.LogFile)
+		if err != nil {
+			tr.log().Warn("Suite requests will not be logged", "suite", suite.Name, "error", err)
+		} else {
+			defer closeLog()
+		}
+	}
+
+	if suite.ArtifactDir != "" {
+		if err := tr.openArtifacts(suite.ArtifactDir); err != nil {
+			tr.log().Warn("Suite artifacts will not be written", "suite", suite.Name, "error", err)
+		} else {
+			// Runs after the result is final, including on early return
+			defer func() {
+				if err := tr.closeArtifacts(&suite, &result); err != nil {
+					tr.log().Warn("Failed to write suite artifacts", "suite", suite.Name, "error", err)
+				}
+			}()
+		}
+	}
+
+	if suite.RequiredHandlerVersion != "" {
+		if reason := tr.checkHandlerVersion(suite.RequiredHandlerVersion); reason != "" {
+			result.Skipped = true
+			result.SkipReason = reason
+			result.Duration = time.Since(start)
+			return result
+		}
+	}
+
+	removed, err := suite.FocusTests()
+	if removed > 0 {
+		tr.log().Warn(`Focus mode: only running the tests marked "only", remove it before committing`,
+			"suite", suite.Name, "left_out", removed)
+	}
+	// Tests depending on each other in a cycle cannot run in any order
+	if err == nil {
+		err = NewDependencyTracker().DetectCycles(suite.Tests)
+	}
+	var order []int
+	if err == nil {
+		order, err = executionOrder(&suite)
+	}
+	if err != nil {
+		result.Skipped = true
+		result.SkipReason = err.Error()
+	}
+
+	ctx, cancel := withSuiteTimeout(ctx, &suite)
+	defer cancel()
+	if !result.Skipped && opts.Retries > 0 && suite.IsStateful() {
+		tr.runSuiteWithRetries(ctx, &suite, order, opts, &result)
+	} else {
+		tr.runSuiteAttempt(ctx, &suite, order, opts, &result)
+	}
+
+	if tr.restartPolicy == RestartAfterSuite {
+		tr.restartHandler(fmt.Sprintf("suite %s finished", suite.Name))
+	}
+
+	result.HandlerRestarts = tr.handlerRestarts - restartsBefore
+	result.Duration = time.Since(start)
+	return result
+}
+
+// runSuiteAttempt runs the tests of a suite between its before-suite and after-suite
+// requests and records their results in result. Tests are only run if the suite is not
+// skipped.
+func (tr *TestRunner) runSuiteAttempt(ctx context.Context, suite *TestSuite, order []int, opts RunOptions, result *TestResult) {
+	if suite.BeforeSuiteRequest != nil && !result.Skipped {
+		if err := tr.sendSuiteRequest(suite.BeforeSuiteRequest); err != nil {
+			result.Skipped = true
+			result.SkipReason = fmt.Sprintf("before_suite_request failed: %v", err)
+		}
+	}
+
+	if !result.Skipped {
+		tr.runTests(ctx, suite, order, opts, result)
+	}
+
+	// The after-suite request runs regardless of the outcome, e.g. to release resources
+	// acquired by a before-suite request that only partially succeeded
+	if suite.AfterSuiteRequest != nil {
+		if err := tr.sendSuiteRequest(suite.AfterSuiteRequest); err != nil {
+			tr.log().Warn("after_suite_request failed", "suite", suite.Name, "error", err)
+		}
+	}
+}
+
+// executionOrder returns the indices of the suite's tests in the order they run
+func executionOrder(suite *TestSuite) ([]int, error) {
+	if suite.Model() == ExecutionDependencyOrdered {
+		order, err := NewDependencyTracker().BuildExecutionOrder(suite.Tests)
+		if err != nil {
+			return nil, fmt.Errorf("cannot order tests by dependency: %w", err)
+		}
+		return order, nil
+	}
+	order := make([]int, len(suite.Tests))
+	for i := range order {
+		order[i] = i
+	}
+	return order, nil
+}
+
+// runTests runs the tests of a suite in the given order and records their results in
+// result
+func (tr *TestRunner) runTests(ctx context.Context, suite *TestSuite, order []int, opts RunOptions, result *TestResult) {
 	// Create dependency tracker to manage test dependencies and build request chains
 	depTracker := NewDependencyTracker()
 
-	result := TestResult{
-		SuiteName:  suite.Name,
-		TotalTests: len(suite.Tests),
+	if len(suite.AssertHandlerEnv) > 0 {
+		if err := tr.checkHandlerEnv(suite.AssertHandlerEnv); err != nil {
+			result.TestResults = append(result.TestResults, SingleTestResult{
+				SuiteName: suite.Name,
+				TestID:    envCheckTestID,
+				Message:   err.Error(),
+			})
+			tr.reportProgress(result.TestResults[len(result.TestResults)-1])
+			result.TotalTests++
+			result.FailedTests++
+		}
 	}
 
 	skipTests := false
+	groups := tr.newTestGroups(suite)
+	if groups != nil {
+		// Finishes the groups whose last test did not run, e.g. because the suite stopped
+		// early or the test was skipped
+		defer groups.closeAll(ctx)
+	}
+
+	// In streaming mode, the tests of eligible suites are sent up front and their
+	// results are collected below as if the tests had run one by one
+	var streamed map[int]SingleTestResult
+	if opts.Retries == 0 && tr.canStream(suite, groups) {
+		streamed = tr.streamTests(ctx, suite, order)
+	}
+
+	// In dependency-ordered suites, a failed test only skips the tests that depend on it
+	var deps [][]int
+	failed := make(map[int]bool)
+	if suite.Model() == ExecutionDependencyOrdered && !suite.ContinueOnFailure {
+		deps, _ = directDependencies(suite.Tests, false)
+	}
 
-	for i := range suite.Tests {
+	// unavailable maps the refs of tests that are never run to a description of the test,
+	// as the tests using them cannot run either
+	unavailable := make(map[string]string)
+
+	for _, i := range order {
 		test := &suite.Tests[i]
 
+		// Disabled and skipped tests are recorded but never run, and do not count towards
+		// the total
+		if test.Disabled {
+			result.TestResults = append(result.TestResults, SingleTestResult{
+				SuiteName:   suite.Name,
+				TestID:      test.Request.ID,
+				Description: test.Description,
+				Fingerprint: test.Fingerprint(),
+				Disabled:    true,
+			})
+			tr.reportProgress(result.TestResults[len(result.TestResults)-1])
+			result.DisabledTests++
+			markUnavailable(unavailable, test, "disabled")
+			continue
+		}
+		if test.Skip {
+			result.TestResults = append(result.TestResults, SingleTestResult{
+				SuiteName:   suite.Name,
+				TestID:      test.Request.ID,
+				Description: test.Description,
+				Fingerprint: test.Fingerprint(),
+				Skipped:     true,
+				Message:     test.SkipReason,
+			})
+			tr.reportProgress(result.TestResults[len(result.TestResults)-1])
+			result.SkippedTests++
+			markUnavailable(unavailable, test, "skipped")
+			continue
+		}
+		if ref, creator := unavailableRef(unavailable, test); ref != "" {
+			result.TestResults = append(result.TestResults, SingleTestResult{
+				SuiteName:   suite.Name,
+				TestID:      test.Request.ID,
+				Description: test.Description,
+				Fingerprint: test.Fingerprint(),
+				Skipped:     true,
+				Message:     fmt.Sprintf("uses ref %s of %s", ref, creator),
+			})
+			tr.reportProgress(result.TestResults[len(result.TestResults)-1])
+			result.SkippedTests++
+			markUnavailable(unavailable, test, "skipped")
+			continue
+		}
+
+		// Tests of an isolated group run against the group's own handler, and the first
+		// test of a group to run sets up the group
+		inGroup := groups != nil && test.Group != ""
+		var prevHandler handlerSlot
+		var groupErr error
+		if inGroup {
+			prevHandler, groupErr = tr.enterGroup(ctx, groups, test.Group)
+		}
+
+		failedDep := -1
+		if deps != nil {
+			for _, dep := range deps[i] {
+				if failed[dep] {
+					failedDep = dep
+					break
+				}
+			}
+		}
+		skipped := skipTests || failedDep >= 0
+
+		var beforeErr error
+		if !skipped && groupErr == nil && suite.BeforeEachTest != nil {
+			beforeErr = tr.sendSuiteRequest(suite.BeforeEachTest)
+		}
+
 		// Run the test case
 		var testResult SingleTestResult
 		if skipTests {
@@ -138,17 +764,61 @@ func (tr *TestRunner) RunTestSuite(ctx context.Context, suite TestSuite, verbosi
 				Passed:  false,
 				Message: "Skipped due to previous test failure in stateful suite",
 			}
+		} else if failedDep >= 0 {
+			testResult = SingleTestResult{
+				TestID:  test.Request.ID,
+				Passed:  false,
+				Message: fmt.Sprintf("Skipped due to failure of dependency %s", suite.Tests[failedDep].Request.ID),
+			}
+		} else if groupErr != nil {
+			testResult = SingleTestResult{
+				TestID:  test.Request.ID,
+				Passed:  false,
+				Message: fmt.Sprintf("group before hook failed: %v", groupErr),
+			}
+		} else if beforeErr != nil {
+			testResult = SingleTestResult{
+				TestID:  test.Request.ID,
+				Skipped: true,
+				Message: fmt.Sprintf("before_each_test failed: %v", beforeErr),
+			}
 		} else {
 			// Build dependency chain by analyzing which refs this test uses
-			if verbosity != VerbosityQuiet {
-				depTracker.BuildDependenciesForTest(i, test)
+			if opts.Verbosity != VerbosityQuiet {
+				if err := depTracker.BuildDependenciesForTest(i, test); err != nil {
+					// The handler reports the undefined ref; only the request chain is incomplete
+					tr.log().Warn("Cannot build request chain", "suite", suite.Name, "error", err)
+				}
 			}
 
 			// Execute the test against the handler
-			testResult = tr.runTest(ctx, test)
+			stderrMark := tr.stderrMark()
+			if streamed != nil {
+				testResult = streamed[i]
+			} else {
+				// Stateful suites are retried as a whole, see runSuiteWithRetries
+				retryCount := test.RetryCount
+				if !suite.IsStateful() {
+					retryCount = max(retryCount, opts.Retries)
+				}
+				testResult = tr.runTestWithRetries(ctx, suite, groups.withoutGroupHooks(i, test), retryCount)
+			}
+			request := test.Request
+			testResult.Request = &request
+			tr.checkSLO(suite, test, &testResult)
+
+			if suite.AfterEachTest != nil {
+				if err := tr.sendSuiteRequest(suite.AfterEachTest); err != nil {
+					tr.log().Warn("after_each_test failed", "suite", suite.Name, "test", test.Request.ID, "error", err)
+				}
+			}
 
 			// Add verbose output if requested or on failure
-			if (verbosity == VerbosityAlways) || (verbosity == VerbosityOnFailure && !testResult.Passed) {
+			if (opts.Verbosity == VerbosityAlways) || (opts.Verbosity == VerbosityOnFailure && !testResult.Passed) {
+				// Streamed tests overlap, so their stderr output cannot be told apart
+				if streamed == nil {
+					testResult.HandlerStderr = tr.handlerStderr(stderrMark)
+				}
 				requestChain := depTracker.BuildRequestChain(i, suite.Tests)
 				verboseOutput := formatVerboseOutput(suite.Tests, i, requestChain, &testResult)
 				if testResult.Message != "" {
@@ -158,24 +828,72 @@ func (tr *TestRunner) RunTestSuite(ctx context.Context, suite TestSuite, verbosi
 				}
 			}
 
-			if verbosity != VerbosityQuiet {
+			if opts.Verbosity != VerbosityQuiet {
 				depTracker.OnTestExecuted(i, test)
 			}
 		}
 
+		switch {
+		case streamed != nil:
+			// Restarts of streamed suites are handled by streamTests
+		case tr.restartPolicy == RestartOnFailure && !testResult.Passed && !testResult.Skipped && !skipped && !suite.IsStateful():
+			tr.restartHandler(fmt.Sprintf("test %s failed", test.Request.ID))
+		case tr.restartPolicy == RestartAlways && !suite.IsStateful():
+			tr.restartHandler(fmt.Sprintf("test %s finished", test.Request.ID))
+		}
+
+		if inGroup {
+			tr.leaveGroup(ctx, groups, test.Group, i, prevHandler)
+		}
+
 		// Collect test case result
+		testResult.SuiteName = suite.Name
+		testResult.Description = test.Description
+		testResult.Fingerprint = test.Fingerprint()
 		result.TestResults = append(result.TestResults, testResult)
+		tr.reportProgress(testResult)
+		if testResult.ValidationError != nil {
+			result.ValidationErrors = append(result.ValidationErrors, testResult.ValidationError)
+		}
+		// Tests skipped because before_each_test failed do not count towards the total
+		if testResult.Skipped {
+			result.SkippedTests++
+			markUnavailable(unavailable, test, "skipped")
+			continue
+		}
+		result.TotalTests++
 		if testResult.Passed {
 			result.PassedTests++
 		} else {
 			result.FailedTests++
-			if suite.Stateful {
+			failed[i] = true
+			if suite.Model() == ExecutionSequential && !suite.ContinueOnFailure {
 				skipTests = true
 			}
+			if opts.FailFast {
+				return
+			}
 		}
 	}
+}
+
+// markUnavailable records the ref created by a test that is not run, if any, in
+// unavailable, describing the test by why it is not run
+func markUnavailable(unavailable map[string]string, test *TestCase, why string) {
+	if test.Request.Ref != "" {
+		unavailable[test.Request.Ref] = fmt.Sprintf("%s test %s", why, test.Request.ID)
+	}
+}
 
-	return result
+// unavailableRef returns the first ref used by a test that is in unavailable, and the
+// description of the test creating it, or empty strings if there is none
+func unavailableRef(unavailable map[string]string, test *TestCase) (ref, creator string) {
+	for _, ref := range extractRefsFromParams(test.Request.Params) {
+		if creator, ok := unavailable[ref]; ok {
+			return ref, creator
+		}
+	}
+	return "", ""
 }
 
 // runTest executes a single test case by sending a request, reading the response,
@@ -187,35 +905,79 @@ func (tr *TestRunner) runTest(ctx context.Context, test *TestCase) SingleTestRes
 		return SingleTestResult{
 			TestID:  test.Request.ID,
 			Passed:  false,
-			Message: fmt.Sprintf("Total execution timeout exceeded (%v)", tr.timeout),
+			Message: tr.timeoutMessage(ctx),
 		}
 	default:
 	}
 
+	if tr.abortOnCancel {
+		defer tr.killHandlerOnDone(ctx)()
+	}
+	if test.TimeoutMs > 0 {
+		defer tr.overrideHandlerTimeout(test.TimeoutValue())()
+	}
+
+	if test.AfterHook != nil {
+		defer test.AfterHook(ctx)
+	}
+	if test.BeforeHook != nil {
+		if err := test.BeforeHook(ctx); err != nil {
+			return SingleTestResult{
+				TestID:  test.Request.ID,
+				Passed:  false,
+				Message: fmt.Sprintf("before hook failed: %v", err),
+			}
+		}
+	}
+
+	stderrMark := tr.stderrMark()
 	err := tr.SendRequest(test.Request)
 	if err != nil {
 		return SingleTestResult{
 			TestID:  test.Request.ID,
 			Passed:  false,
-			Message: fmt.Sprintf("Failed to send request: %v", err),
+			Message: fmt.Sprintf("Failed to send request: %v", tr.abortErr(ctx, err)),
 		}
 	}
 
 	resp, err := tr.ReadResponse()
 	if err != nil {
+		message := fmt.Sprintf("Failed to read response: %v", tr.abortErr(ctx, err))
+		if test.TimeoutMs > 0 && errors.Is(err, ErrHandlerTimeout) {
+			message = fmt.Sprintf("Test timeout exceeded: no response within timeout_ms (%v)", test.TimeoutValue())
+		}
 		return SingleTestResult{
 			TestID:  test.Request.ID,
 			Passed:  false,
-			Message: fmt.Sprintf("Failed to read response: %v", err),
+			Message: message,
 		}
 	}
 
-	if err := validateResponse(test, resp); err != nil {
+	return tr.checkResponse(test, resp, stderrMark)
+}
+
+// checkResponse validates the response received for a test, and the handler's stderr
+// output since stderrMark if the test expects a log entry
+func (tr *TestRunner) checkResponse(test *TestCase, resp *Response, stderrMark int) SingleTestResult {
+	if validationErr := validateResponse(test, resp); validationErr != nil {
 		return SingleTestResult{
 			TestID:           test.Request.ID,
 			Passed:           false,
-			Message:          fmt.Sprintf("Invalid response: %s", err.Error()),
+			Message:          fmt.Sprintf("Invalid response: %s", validationErr.Message),
 			ReceivedResponse: resp,
+			ValidationError:  validationErr,
+		}
+	}
+
+	if test.ExpectedLogEntry != nil {
+		if err := tr.checkLogEntry(test.ExpectedLogEntry, stderrMark); err != nil {
+			return SingleTestResult{
+				TestID:           test.Request.ID,
+				Passed:           false,
+				Message:          err.Error(),
+				ReceivedResponse: resp,
+				ValidationError:  newValidationError(ReasonLogEntryMissing, test, "%v", err),
+			}
 		}
 	}
 
@@ -228,7 +990,7 @@ func (tr *TestRunner) runTest(ctx context.Context, test *TestCase) SingleTestRes
 
 // validateResponse validates that a response matches the expected test outcome.
 // Returns an error if the response does not match the expected outcome (error or success).
-func validateResponse(test *TestCase, resp *Response) error {
+func validateResponse(test *TestCase, resp *Response) *ValidationError {
 	if test.ExpectedResponse.Error != nil {
 		return validateResponseForError(test, resp)
 	}
@@ -239,35 +1001,35 @@ func validateResponse(test *TestCase, resp *Response) error {
 // validateResponseForError validates that a response correctly represents an error case.
 // It ensures the response contains an error, the result is null or omitted, and if an
 // error code is expected, it matches the expected type and member.
-func validateResponseForError(test *TestCase, resp *Response) error {
+func validateResponseForError(test *TestCase, resp *Response) *ValidationError {
 	if test.ExpectedResponse.Error == nil {
 		panic("validateResponseForError expects non-nil error")
 	}
 
 	if resp.Error == nil {
 		if test.ExpectedResponse.Error.Code != nil {
-			return fmt.Errorf("expected error %s.%s, but got no error",
+			return newValidationError(ReasonUnexpectedSuccess, test, "expected error %s.%s, but got no error",
 				test.ExpectedResponse.Error.Code.Type, test.ExpectedResponse.Error.Code.Member)
 		}
-		return fmt.Errorf("expected error, but got no error")
+		return newValidationError(ReasonUnexpectedSuccess, test, "expected error, but got no error")
 	}
 
 	if !resp.Result.IsNullOrOmitted() {
-		return fmt.Errorf("expected result to be null or omitted when error is present, got: %s", string(resp.Result))
+		return newValidationError(ReasonResultMismatch, test, "expected result to be null or omitted when error is present, got: %s", string(resp.Result))
 	}
 
 	if test.ExpectedResponse.Error.Code != nil {
 		if resp.Error.Code == nil {
-			return fmt.Errorf("expected error code %s.%s, but got error with no code",
+			return newValidationError(ReasonErrorCodeMismatch, test, "expected error code %s.%s, but got error with no code",
 				test.ExpectedResponse.Error.Code.Type, test.ExpectedResponse.Error.Code.Member)
 		}
 
 		if resp.Error.Code.Type != test.ExpectedResponse.Error.Code.Type {
-			return fmt.Errorf("expected error type %s, got %s", test.ExpectedResponse.Error.Code.Type, resp.Error.Code.Type)
+			return newValidationError(ReasonErrorCodeMismatch, test, "expected error type %s, got %s", test.ExpectedResponse.Error.Code.Type, resp.Error.Code.Type)
 		}
 
 		if resp.Error.Code.Member != test.ExpectedResponse.Error.Code.Member {
-			return fmt.Errorf("expected error member %s, got %s", test.ExpectedResponse.Error.Code.Member, resp.Error.Code.Member)
+			return newValidationError(ReasonErrorCodeMismatch, test, "expected error member %s, got %s", test.ExpectedResponse.Error.Code.Member, resp.Error.Code.Member)
 		}
 	}
 	return nil
@@ -276,37 +1038,41 @@ func validateResponseForError(test *TestCase, resp *Response) error {
 // validateResponseForSuccess validates that a response correctly represents a success case.
 // It ensures the response contains no error, and if a result is expected, it matches the
 // expected value.
-func validateResponseForSuccess(test *TestCase, resp *Response) error {
+func validateResponseForSuccess(test *TestCase, resp *Response) *ValidationError {
 	if test.ExpectedResponse.Error != nil {
 		panic("validateResponseForSuccess expects nil error")
 	}
 
 	if resp.Error != nil {
 		if resp.Error.Code != nil {
-			return fmt.Errorf("expected success with no error, but got error: %s.%s", resp.Error.Code.Type, resp.Error.Code.Member)
+			return newValidationError(ReasonUnexpectedError, test, "expected success with no error, but got error: %s.%s", resp.Error.Code.Type, resp.Error.Code.Member)
 		}
-		return fmt.Errorf("expected success with no error, but got error")
+		return newValidationError(ReasonUnexpectedError, test, "expected success with no error, but got error")
 	}
 
 	if test.ExpectedResponse.Result.IsNullOrOmitted() {
 		if !resp.Result.IsNullOrOmitted() {
-			return fmt.Errorf("expected null or omitted result, got: %s", string(resp.Result))
+			return newValidationError(ReasonResultMismatch, test, "expected null or omitted result, got: %s", string(resp.Result))
 		}
 		return nil
 	}
 
 	if resp.Result.IsNullOrOmitted() {
-		return fmt.Errorf("expected result with value, got null or omitted result")
+		return newValidationError(ReasonResultMismatch, test, "expected result with value, got null or omitted result")
+	}
+
+	if err := validateJSONStructure(resp.Result); err != nil {
+		return newValidationError(ReasonInvalidResult, test, "%v", err)
 	}
 
 	// If the request has a ref field, validate that the response is a reference object
 	if test.Request.Ref != "" {
 		refValue, ok := ParseRefObject(resp.Result)
 		if !ok {
-			return fmt.Errorf("expected reference object result for request with ref field, got: %s", string(resp.Result))
+			return newValidationError(ReasonRefMismatch, test, "expected reference object result for request with ref field, got: %s", string(resp.Result))
 		}
 		if refValue != test.Request.Ref {
-			return fmt.Errorf("reference mismatch: expected ref %q, got %q", test.Request.Ref, refValue)
+			return newValidationError(ReasonRefMismatch, test, "reference mismatch: expected ref %q, got %q", test.Request.Ref, refValue)
 		}
 		return nil
 	}
@@ -314,39 +1080,105 @@ func validateResponseForSuccess(test *TestCase, resp *Response) error {
 	// For non-ref results, normalize and compare
 	expectedNorm, err := test.ExpectedResponse.Result.Normalize()
 	if err != nil {
-		return fmt.Errorf("failed to normalize expected result: %w", err)
+		return newValidationError(ReasonInvalidResult, test, "failed to normalize expected result: %v", err)
 	}
 
 	actualNorm, err := resp.Result.Normalize()
 	if err != nil {
-		return fmt.Errorf("failed to normalize actual result: %w", err)
+		return newValidationError(ReasonInvalidResult, test, "failed to normalize actual result: %v", err)
 	}
 
 	if expectedNorm != actualNorm {
-		return fmt.Errorf("result mismatch: expected %s, got %s", expectedNorm, actualNorm)
+		diff := DiffJSON(test.ExpectedResponse.Result, resp.Result)
+		return newValidationError(ReasonResultMismatch, test, "result mismatch (- expected, + got):\n      %s",
+			strings.ReplaceAll(diff, "\n", "\n      "))
+	}
+
+	for _, field := range test.RequiredResultFields {
+		if !resp.Result.HasField(field) {
+			return newValidationError(ReasonMissingField, test, "result missing required field: %s", field)
+		}
 	}
 	return nil
 }
 
+// validateJSONStructure checks that the result is well-formed JSON, so that a handler
+// returning e.g. an unquoted string is reported as such rather than as a normalization
+// failure
+func validateJSONStructure(r Result) error {
+	if json.Valid(r) {
+		return nil
+	}
+	const maxExcerpt = 64
+	excerpt := string(r)
+	if len(excerpt) > maxExcerpt {
+		excerpt = excerpt[:maxExcerpt] + "..."
+	}
+	return fmt.Errorf("response result is not valid JSON: %s", excerpt)
+}
+
 // TestResult contains results from running a test suite
 type TestResult struct {
-	SuiteName   string
-	TotalTests  int
-	PassedTests int
-	FailedTests int
-	TestResults []SingleTestResult
+	SuiteName     string             `json:"suite_name"`
+	SuiteMetadata map[string]string  `json:"suite_metadata,omitempty"` // Pass-through copy of TestSuite.Metadata
+	TotalTests    int                `json:"total_tests"`
+	PassedTests   int                `json:"passed_tests"`
+	FailedTests   int                `json:"failed_tests"`
+	DisabledTests int                `json:"disabled_tests,omitempty"` // Not included in TotalTests
+	SkippedTests  int                `json:"skipped_tests,omitempty"`  // Tests with TestCase.Skip set; not included in TotalTests
+	Skipped       bool               `json:"skipped,omitempty"`        // Suite was not run (see SkipReason)
+	SkipReason    string             `json:"skip_reason,omitempty"`    // Why the suite was skipped
+	TestResults   []SingleTestResult `json:"test_results"`
+	// ValidationErrors collects the ValidationError of every test result that has one
+	ValidationErrors []*ValidationError `json:"validation_errors,omitempty"`
+	HandlerRestarts  int                `json:"handler_restarts,omitempty"` // Handlers replaced by the restart policy or after a failed read/write
+	Retries          int                `json:"retries,omitempty"`          // Times the stateful suite was run again from the beginning, see RunOptions.Retries
+	Duration         time.Duration      `json:"duration_ns"`                // Wall-clock time spent running the suite
 }
 
 // SingleTestResult contains the result of a single test
 type SingleTestResult struct {
-	TestID           string
-	Passed           bool
-	Message          string
-	ReceivedResponse *Response // The actual response received from the handler
+	SuiteName        string           `json:"suite_name"` // Name of the suite the test belongs to
+	TestID           string           `json:"test_id"`
+	Description      string           `json:"description,omitempty"` // Copy of TestCase.Description
+	Fingerprint      string           `json:"fingerprint,omitempty"` // See TestCase.Fingerprint
+	Passed           bool             `json:"passed"`
+	Disabled         bool             `json:"disabled,omitempty"` // Test was disabled and not run
+	Skipped          bool             `json:"skipped,omitempty"`  // Test was skipped and not run; Message is the skip reason
+	Message          string           `json:"message,omitempty"`
+	Request          *Request         `json:"request,omitempty"`           // The request of the test, unless it was skipped
+	ReceivedResponse *Response        `json:"received_response,omitempty"` // The actual response received from the handler
+	ValidationError  *ValidationError `json:"validation_error,omitempty"`  // Why the response did not meet the test's expectations, if it did not
+	Duration         time.Duration    `json:"duration_ns"`                 // Time spent sending the request and reading the response
+	Retries          int              `json:"retries,omitempty"`           // Number of failed attempts before the recorded one, see TestCase.RetryCount
+	HandlerStderr    string           `json:"handler_stderr,omitempty"`    // What the handler wrote to stderr while the test ran, only for tests with verbose output
 }
 
-// LoadTestSuiteFromFS loads a test suite from an embedded filesystem
-func LoadTestSuiteFromFS(fsys embed.FS, filePath string) (*TestSuite, error) {
+// MergeTestResults combines results from multiple suites into a single result.
+// The merged suite name joins the individual suite names, and each SingleTestResult
+// keeps the name of the suite it came from.
+func MergeTestResults(results ...TestResult) TestResult {
+	var merged TestResult
+	names := make([]string, 0, len(results))
+	for _, r := range results {
+		names = append(names, r.SuiteName)
+		merged.TotalTests += r.TotalTests
+		merged.PassedTests += r.PassedTests
+		merged.FailedTests += r.FailedTests
+		merged.DisabledTests += r.DisabledTests
+		merged.SkippedTests += r.SkippedTests
+		merged.HandlerRestarts += r.HandlerRestarts
+		merged.Retries += r.Retries
+		merged.Duration += r.Duration
+		merged.TestResults = append(merged.TestResults, r.TestResults...)
+		merged.ValidationErrors = append(merged.ValidationErrors, r.ValidationErrors...)
+	}
+	merged.SuiteName = strings.Join(names, ", ")
+	return merged
+}
+
+// LoadTestSuiteFromFS loads a test suite from a filesystem (typically the embedded testdata.FS)
+func LoadTestSuiteFromFS(fsys fs.FS, filePath string) (*TestSuite, error) {
 	data, err := fs.ReadFile(fsys, filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
@@ -357,6 +1189,78 @@ func LoadTestSuiteFromFS(fsys embed.FS, filePath string) (*TestSuite, error) {
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
+	if suite.EstimatedDuration != "" {
+		if _, err := time.ParseDuration(suite.EstimatedDuration); err != nil {
+			return nil, fmt.Errorf("invalid estimated_duration %q: %w", suite.EstimatedDuration, err)
+		}
+	}
+
+	if suite.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("timeout_seconds must not be negative, got %d", suite.TimeoutSeconds)
+	}
+
+	for _, entry := range suite.HandlerEnv {
+		if key, _, ok := strings.Cut(entry, "="); !ok || key == "" {
+			return nil, fmt.Errorf("handler_env entry %q is not of the form KEY=value", entry)
+		}
+	}
+
+	if err := validateTestIDs(&suite); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateSuiteIDs([]TestSuite{suite}); err != nil {
+		return nil, err
+	}
+
+	if err := validateDependsOn(&suite); err != nil {
+		return nil, err
+	}
+
+	for _, test := range suite.Tests {
+		if test.TimeoutMs < 0 {
+			return nil, fmt.Errorf("test %s: timeout_ms must not be negative, got %d", test.Request.ID, test.TimeoutMs)
+		}
+		if test.RetryCount < 0 {
+			return nil, fmt.Errorf("test %s: retry_count must not be negative, got %d", test.Request.ID, test.RetryCount)
+		}
+		if test.ExpectedResponseTime != "" {
+			if _, err := time.ParseDuration(test.ExpectedResponseTime); err != nil {
+				return nil, fmt.Errorf("test %s: invalid expected_response_time %q: %w", test.Request.ID, test.ExpectedResponseTime, err)
+			}
+		}
+	}
+
+	switch suite.ExecutionModel {
+	case "", ExecutionSequential, ExecutionParallel:
+	case ExecutionDependencyOrdered:
+		if _, err := NewDependencyTracker().BuildExecutionOrder(suite.Tests); err != nil {
+			return nil, fmt.Errorf("invalid dependency_ordered suite: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid execution_model %q (expected sequential, parallel, or dependency_ordered)", suite.ExecutionModel)
+	}
+
+	if suite.RequiredHandlerVersion != "" {
+		if err := ValidateVersionConstraint(suite.RequiredHandlerVersion); err != nil {
+			return nil, fmt.Errorf("invalid required_handler_version: %w", err)
+		}
+	}
+
+	if err := ValidateVersionRange(suite.MinimumHandlerVersion, suite.MaximumHandlerVersion); err != nil {
+		return nil, fmt.Errorf("invalid handler version range: %w", err)
+	}
+
+	if err := ValidateVersionRange("", suite.SuiteVersion); err != nil {
+		return nil, fmt.Errorf("invalid suite_version: %w", err)
+	}
+	for _, test := range suite.Tests {
+		// A test cannot have been added in a version later than the suite's
+		if err := ValidateVersionRange(test.AddedInVersion, suite.SuiteVersion); err != nil {
+			return nil, fmt.Errorf("test %s: invalid added_in_version: %w", test.Request.ID, err)
+		}
+	}
+
 	// Set suite name from filename if not specified
 	if suite.Name == "" {
 		suite.Name = filepath.Base(filePath)
@@ -423,6 +1327,16 @@ func formatVerboseOutput(allTests []TestCase, testIdx int, requestChain []int, t
 		result.WriteString("\n")
 	}
 
+	if testResult.HandlerStderr != "" {
+		result.WriteString("\n      Handler stderr\n")
+		result.WriteString("      ────────────────────────────────────────\n")
+		for _, line := range strings.Split(testResult.HandlerStderr, "\n") {
+			result.WriteString("      ")
+			result.WriteString(line)
+			result.WriteString("\n")
+		}
+	}
+
 	result.WriteString("\n")
 	return result.String()
 }
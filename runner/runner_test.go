@@ -1,9 +1,21 @@
 package runner
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func TestValidateResponse(t *testing.T) {
@@ -273,3 +285,984 @@ func TestValidateResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestTestSuite_MetadataRoundTrip(t *testing.T) {
+	fsys := fstest.MapFS{
+		"suite.json": &fstest.MapFile{Data: []byte(`{
+			"name": "Metadata Suite",
+			"metadata": {
+				"spec_section": "4.2",
+				"issue": "https://github.com/stringintech/kernel-bindings-tests/issues/1"
+			},
+			"tests": []
+		}`)},
+	}
+	want := map[string]string{
+		"spec_section": "4.2",
+		"issue":        "https://github.com/stringintech/kernel-bindings-tests/issues/1",
+	}
+
+	suite, err := LoadTestSuiteFromFS(fsys, "suite.json")
+	if err != nil {
+		t.Fatalf("failed to load test suite: %v", err)
+	}
+	if !maps.Equal(suite.Metadata, want) {
+		t.Fatalf("loaded metadata = %v, want %v", suite.Metadata, want)
+	}
+
+	// The suite has no tests, so no handler process is spawned
+	tr := &TestRunner{}
+	result := tr.RunTestSuite(context.Background(), *suite, RunOptions{})
+	if !maps.Equal(result.SuiteMetadata, want) {
+		t.Fatalf("result metadata = %v, want %v", result.SuiteMetadata, want)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("failed to marshal result: %v", err)
+	}
+	var decoded TestResult
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+	if !maps.Equal(decoded.SuiteMetadata, want) {
+		t.Errorf("decoded metadata = %v, want %v", decoded.SuiteMetadata, want)
+	}
+}
+
+func TestNewTestRunnerInProcess(t *testing.T) {
+	var suite TestSuite
+	if err := json.Unmarshal([]byte(`{
+		"name": "In-Process Suite",
+		"tests": [
+			{
+				"request": {"id": "1", "method": "echo", "params": {"value": true}},
+				"expected_response": {"result": true}
+			},
+			{
+				"request": {"id": "2", "method": "echo", "params": {"value": false}},
+				"expected_response": {"result": true}
+			},
+			{
+				"request": {"id": "3", "method": "unknown"},
+				"expected_response": {"error": {}}
+			}
+		]
+	}`), &suite); err != nil {
+		t.Fatalf("failed to unmarshal suite: %v", err)
+	}
+
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		if req.Method != "echo" {
+			return Response{Error: &Error{}}
+		}
+		var params struct {
+			Value bool `json:"value"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return Response{Error: &Error{}}
+		}
+		result, _ := json.Marshal(params.Value)
+		return Response{Result: result}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	if result.TotalTests != 3 || result.PassedTests != 2 || result.FailedTests != 1 {
+		t.Fatalf("unexpected counts: total=%d passed=%d failed=%d",
+			result.TotalTests, result.PassedTests, result.FailedTests)
+	}
+	if result.TestResults[1].Passed {
+		t.Errorf("expected test 2 to fail")
+	}
+	if !strings.Contains(result.TestResults[1].Message, "result mismatch") {
+		t.Errorf("expected result mismatch message, got %q", result.TestResults[1].Message)
+	}
+}
+
+func TestRunTestSuite_DisabledAndSkippedTests(t *testing.T) {
+	var suite TestSuite
+	if err := json.Unmarshal([]byte(`{
+		"name": "Disabled Suite",
+		"tests": [
+			{"request": {"id": "1", "method": "m"}, "expected_response": {"result": true}},
+			{"request": {"id": "2", "method": "m"}, "expected_response": {"result": false}, "disabled": true},
+			{"request": {"id": "3", "method": "m"}, "expected_response": {"result": false}, "skip": true, "skip_reason": "mainnet only"}
+		]
+	}`), &suite); err != nil {
+		t.Fatalf("failed to unmarshal suite: %v", err)
+	}
+
+	var calls []string
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		calls = append(calls, req.ID)
+		return Response{Result: Result(`true`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	if result.TotalTests != 1 || result.PassedTests != 1 || result.DisabledTests != 1 || result.SkippedTests != 1 {
+		t.Fatalf("unexpected counts: total=%d passed=%d disabled=%d skipped=%d",
+			result.TotalTests, result.PassedTests, result.DisabledTests, result.SkippedTests)
+	}
+	if !result.TestResults[1].Disabled {
+		t.Errorf("expected test 2 to be marked disabled")
+	}
+	if skipped := result.TestResults[2]; !skipped.Skipped || skipped.Passed || skipped.Message != "mainnet only" {
+		t.Errorf("expected test 3 to be skipped with its reason, got %+v", skipped)
+	}
+	if len(calls) != 1 || calls[0] != "1" {
+		t.Errorf("expected only test 1 to reach the handler, got %v", calls)
+	}
+}
+
+// TestRunTestSuite_RefOfTestNotRun tests that the tests using a ref created by a test
+// that is not run are skipped, instead of failing to resolve the ref
+func TestRunTestSuite_RefOfTestNotRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		creator string
+		want    string
+	}{
+		{name: "disabled creator", creator: `"disabled": true`, want: "uses ref $a of disabled test create"},
+		{name: "skipped creator", creator: `"skip": true, "skip_reason": "mainnet only"`, want: "uses ref $a of skipped test create"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite TestSuite
+			if err := json.Unmarshal([]byte(`{
+				"name": "Refs",
+				"stateful": true,
+				"tests": [
+					{"request": {"id": "create", "method": "m", "ref": "$a"}, "expected_response": {"result": {"ref": "$a"}}, `+tt.creator+`},
+					{"request": {"id": "use", "method": "m", "params": {"x": {"ref": "$a"}}, "ref": "$b"}, "expected_response": {"result": {"ref": "$b"}}},
+					{"request": {"id": "use_b", "method": "m", "params": {"x": {"ref": "$b"}}}, "expected_response": {"result": true}},
+					{"request": {"id": "other", "method": "m"}, "expected_response": {"result": true}}
+				]
+			}`), &suite); err != nil {
+				t.Fatalf("failed to unmarshal suite: %v", err)
+			}
+
+			var calls []string
+			tr, err := NewTestRunnerInProcess(func(req Request) Response {
+				calls = append(calls, req.ID)
+				return Response{Result: Result(`true`)}
+			}, 0)
+			if err != nil {
+				t.Fatalf("failed to create in-process runner: %v", err)
+			}
+			defer tr.CloseHandler()
+
+			result := tr.RunTestSuite(context.Background(), suite, RunOptions{Verbosity: VerbosityOnFailure})
+			if result.PassedTests != 1 || result.FailedTests != 0 || result.SkippedTests < 2 {
+				t.Fatalf("unexpected counts: passed=%d failed=%d skipped=%d", result.PassedTests, result.FailedTests, result.SkippedTests)
+			}
+			if use := result.TestResults[1]; !use.Skipped || use.Message != tt.want {
+				t.Errorf("expected use to be skipped with %q, got %+v", tt.want, use)
+			}
+			if useB := result.TestResults[2]; !useB.Skipped || useB.Message != "uses ref $b of skipped test use" {
+				t.Errorf("expected use_b to be skipped as well, got %+v", useB)
+			}
+			if len(calls) != 1 || calls[0] != "other" {
+				t.Errorf("expected only the unrelated test to reach the handler, got %v", calls)
+			}
+		})
+	}
+}
+
+func TestRunTestSuite_Only(t *testing.T) {
+	var calls []string
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		calls = append(calls, req.ID)
+		return Response{Result: Result(`true`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	suite := TestSuite{
+		Name: "Focus",
+		Tests: []TestCase{
+			{Request: Request{ID: "1", Method: "m"}, Description: "first", ExpectedResponse: Response{Result: Result(`true`)}},
+			{Request: Request{ID: "2", Method: "m"}, Description: "second", ExpectedResponse: Response{Result: Result(`true`)}, Only: true},
+			{Request: Request{ID: "3", Method: "m"}, Description: "third", ExpectedResponse: Response{Result: Result(`true`)}},
+		},
+	}
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	if result.TotalTests != 1 || len(calls) != 1 || calls[0] != "2" {
+		t.Errorf("expected only test 2 to run, got total=%d calls=%v", result.TotalTests, calls)
+	}
+	// The result of the focused test is the first one, but must keep its own description
+	if len(result.TestResults) != 1 || result.TestResults[0].Description != "second" {
+		t.Errorf("expected the result of test 2 with its description, got %+v", result.TestResults)
+	}
+	if len(suite.Tests) != 3 {
+		t.Errorf("expected the caller's suite to keep all tests, got %d", len(suite.Tests))
+	}
+}
+
+func TestMergeTestResults(t *testing.T) {
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		return Response{Result: Result(`true`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	newSuite := func(name string, ids ...string) TestSuite {
+		suite := TestSuite{Name: name}
+		for _, id := range ids {
+			suite.Tests = append(suite.Tests, TestCase{
+				Request:          Request{ID: id, Method: "m"},
+				ExpectedResponse: Response{Result: Result(`true`)},
+			})
+		}
+		return suite
+	}
+
+	a := tr.RunTestSuite(context.Background(), newSuite("a", "1", "2"), RunOptions{})
+	b := tr.RunTestSuite(context.Background(), newSuite("b", "1"), RunOptions{})
+	merged := MergeTestResults(a, b)
+
+	if merged.TotalTests != 3 || merged.PassedTests != 3 {
+		t.Fatalf("unexpected counts: total=%d passed=%d", merged.TotalTests, merged.PassedTests)
+	}
+
+	var got []string
+	for _, r := range merged.TestResults {
+		got = append(got, r.SuiteName+"::"+r.TestID)
+	}
+	want := []string{"a::1", "a::2", "b::1"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("merged test results = %v, want %v", got, want)
+	}
+}
+
+func TestRunTestSuite_RestartPolicy(t *testing.T) {
+	suite := TestSuite{
+		Name: "Restart Suite",
+		Tests: []TestCase{
+			{Request: Request{ID: "1", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+			{Request: Request{ID: "2", Method: "m"}, ExpectedResponse: Response{Result: Result(`false`)}},
+			{Request: Request{ID: "3", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+		},
+	}
+
+	tests := []struct {
+		policy          RestartPolicy
+		wantSpawns      int
+		wantRestarts    int
+		wantHandlerLive bool
+	}{
+		{RestartNever, 1, 0, true},
+		{RestartOnFailure, 2, 1, true},
+		{RestartAfterSuite, 1, 1, false},
+		{RestartAlways, 3, 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			spawns := 0
+			tr := &TestRunner{
+				restartPolicy: tt.policy,
+				newHandler: func() (HandlerInterface, error) {
+					spawns++
+					return &inProcessHandler{fn: func(req Request) Response {
+						return Response{Result: Result(`true`)}
+					}}, nil
+				},
+			}
+			defer tr.CloseHandler()
+
+			result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+			if spawns != tt.wantSpawns {
+				t.Errorf("expected %d handler spawns, got %d", tt.wantSpawns, spawns)
+			}
+			if result.HandlerRestarts != tt.wantRestarts {
+				t.Errorf("expected %d handler restarts, got %d", tt.wantRestarts, result.HandlerRestarts)
+			}
+			if (tr.handler != nil) != tt.wantHandlerLive {
+				t.Errorf("expected handler live=%v after suite, got %v", tt.wantHandlerLive, tr.handler != nil)
+			}
+		})
+	}
+}
+
+func TestRunTestSuite_RestartOnFailureKeepsStatefulHandler(t *testing.T) {
+	// The test using $x only passes against the handler that created it
+	newHandler := func() (HandlerInterface, error) {
+		created := false
+		return &inProcessHandler{fn: func(req Request) Response {
+			switch req.ID {
+			case "a":
+				created = true
+				return Response{Result: Result(`{"ref": "$x"}`)}
+			case "c":
+				return Response{Result: Result(strconv.FormatBool(created))}
+			}
+			return Response{Result: Result(`true`)}
+		}}, nil
+	}
+	tests := []TestCase{
+		{Request: Request{ID: "a", Method: "m", Ref: "$x"}, ExpectedResponse: Response{Result: Result(`{"ref": "$x"}`)}},
+		{Request: Request{ID: "b", Method: "m"}, ExpectedResponse: Response{Result: Result(`false`)}},
+		{Request: Request{ID: "c", Method: "m", Params: json.RawMessage(`{"x":{"ref":"$x"}}`)}, ExpectedResponse: Response{Result: Result(`true`)}},
+	}
+
+	for _, suite := range []TestSuite{
+		{Name: "dependency_ordered", ExecutionModel: ExecutionDependencyOrdered, Tests: tests},
+		{Name: "sequential", ExecutionModel: ExecutionSequential, ContinueOnFailure: true, Tests: tests},
+	} {
+		t.Run(suite.Name, func(t *testing.T) {
+			tr := &TestRunner{restartPolicy: RestartOnFailure, newHandler: newHandler}
+			defer tr.CloseHandler()
+
+			result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+			if result.PassedTests != 2 || result.FailedTests != 1 {
+				t.Errorf("expected only b to fail, got %+v", result.TestResults)
+			}
+			if result.HandlerRestarts != 0 {
+				t.Errorf("expected no handler restarts in a stateful suite, got %d", result.HandlerRestarts)
+			}
+		})
+	}
+}
+
+func TestParseRestartPolicy(t *testing.T) {
+	for _, policy := range []RestartPolicy{RestartNever, RestartOnFailure, RestartAfterSuite, RestartAlways} {
+		parsed, err := ParseRestartPolicy(policy.String())
+		if err != nil || parsed != policy {
+			t.Errorf("ParseRestartPolicy(%q) = %v, %v", policy.String(), parsed, err)
+		}
+	}
+	if _, err := ParseRestartPolicy("sometimes"); err == nil {
+		t.Errorf("expected error for unknown policy")
+	}
+}
+
+func TestLoadTestSuiteFromFS_EstimatedDuration(t *testing.T) {
+	fsys := fstest.MapFS{
+		"valid.json":   &fstest.MapFile{Data: []byte(`{"estimated_duration": "2m30s", "tests": []}`)},
+		"invalid.json": &fstest.MapFile{Data: []byte(`{"estimated_duration": "soon", "tests": []}`)},
+	}
+
+	suite, err := LoadTestSuiteFromFS(fsys, "valid.json")
+	if err != nil {
+		t.Fatalf("failed to load valid suite: %v", err)
+	}
+	if got := suite.EstimatedDurationValue(); got != 150*time.Second {
+		t.Errorf("EstimatedDurationValue() = %v, want 2m30s", got)
+	}
+
+	if _, err := LoadTestSuiteFromFS(fsys, "invalid.json"); err == nil {
+		t.Errorf("expected error for invalid estimated_duration")
+	}
+}
+
+func TestValidateResponse_InvalidJSONResult(t *testing.T) {
+	test := &TestCase{
+		Request:          Request{ID: "1"},
+		ExpectedResponse: Response{Result: Result(`"abc"`)},
+	}
+
+	err := validateResponse(test, &Response{Result: Result(`abc`)})
+	if err == nil || err.Reason != ReasonInvalidResult || err.Message != "response result is not valid JSON: abc" {
+		t.Errorf("expected invalid JSON error, got %v", err)
+	}
+
+	long := strings.Repeat("x", 100)
+	err = validateResponse(test, &Response{Result: Result(long)})
+	if err == nil || !strings.HasSuffix(err.Error(), long[:64]+"...") {
+		t.Errorf("expected truncated excerpt, got %v", err)
+	}
+}
+
+func TestTestCase_JSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		testCase string
+	}{
+		{
+			name: "all optional fields present",
+			testCase: `{
+				"description": "Create context",
+				"request": {"id": "ctx#1", "method": "btck_context_create", "params": {"chain_type": "regtest"}, "ref": "$ctx"},
+				"expected_response": {"result": {"ref": "$ctx"}},
+				"disabled": true,
+				"group": "setup"
+			}`,
+		},
+		{
+			name: "no optional fields",
+			testCase: `{
+				"request": {"id": "1", "method": "m"},
+				"expected_response": {}
+			}`,
+		},
+		{
+			name: "error expected response",
+			testCase: `{
+				"request": {"id": "2", "method": "m", "params": {"flags": 7}},
+				"expected_response": {"error": {"code": {"type": "btck_ScriptVerifyStatus", "member": "ERROR_INVALID_FLAGS_COMBINATION"}}}
+			}`,
+		},
+		{
+			name: "error without code",
+			testCase: `{
+				"request": {"id": "3", "method": "m"},
+				"expected_response": {"error": {}}
+			}`,
+		},
+		{
+			name: "result expected response",
+			testCase: `{
+				"request": {"id": "4", "method": "m"},
+				"expected_response": {"result": [1, "two", {"three": 3.5}]}
+			}`,
+		},
+		{
+			name: "null result",
+			testCase: `{
+				"request": {"id": "5", "method": "m"},
+				"expected_response": {"result": null}
+			}`,
+		},
+		{
+			name: "raw message params",
+			testCase: `{
+				"request": {"id": "6", "method": "m", "params": {"nested": {"list": [true, null, "x"]}, "hex": "00ff"}},
+				"expected_response": {"result": true}
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var original TestCase
+			if err := json.Unmarshal([]byte(tt.testCase), &original); err != nil {
+				t.Fatalf("failed to unmarshal test case: %v", err)
+			}
+
+			data, err := json.Marshal(original)
+			if err != nil {
+				t.Fatalf("failed to marshal test case: %v", err)
+			}
+
+			var roundTripped TestCase
+			if err := json.Unmarshal(data, &roundTripped); err != nil {
+				t.Fatalf("failed to unmarshal marshaled test case: %v", err)
+			}
+
+			normalizeTestCase(t, &original)
+			normalizeTestCase(t, &roundTripped)
+			if !reflect.DeepEqual(original, roundTripped) {
+				t.Errorf("round trip mismatch:\noriginal:      %+v\nround-tripped: %+v\nJSON: %s", original, roundTripped, data)
+			}
+		})
+	}
+}
+
+// normalizeTestCase rewrites the raw JSON fields of a test case into canonical form, and
+// an omitted result as null, so that equivalent test cases compare equal
+func normalizeTestCase(t *testing.T, tc *TestCase) {
+	t.Helper()
+	if len(tc.Request.Params) > 0 {
+		params, err := Result(tc.Request.Params).Normalize()
+		if err != nil {
+			t.Fatalf("failed to normalize params: %v", err)
+		}
+		tc.Request.Params = json.RawMessage(params)
+	}
+	if tc.ExpectedResponse.Result.IsNullOrOmitted() {
+		tc.ExpectedResponse.Result = nil
+		return
+	}
+	result, err := tc.ExpectedResponse.Result.Normalize()
+	if err != nil {
+		t.Fatalf("failed to normalize result: %v", err)
+	}
+	tc.ExpectedResponse.Result = Result(result)
+}
+
+func TestTestSuite_Model(t *testing.T) {
+	tests := []struct {
+		suite TestSuite
+		want  ExecutionModel
+	}{
+		{TestSuite{}, ExecutionParallel},
+		{TestSuite{Stateful: true}, ExecutionSequential},
+		{TestSuite{Stateful: true, ExecutionModel: ExecutionDependencyOrdered}, ExecutionDependencyOrdered},
+		{TestSuite{ExecutionModel: ExecutionSequential}, ExecutionSequential},
+	}
+	for _, tt := range tests {
+		if got := tt.suite.Model(); got != tt.want {
+			t.Errorf("Model() of %+v = %q, want %q", tt.suite, got, tt.want)
+		}
+	}
+}
+
+func TestRunTestSuite_DependencyOrdered(t *testing.T) {
+	var sent []string
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		sent = append(sent, req.ID)
+		if req.ID == "create_b" {
+			return Response{Error: &Error{}}
+		}
+		if req.Ref != "" {
+			return Response{Result: Result(`{"ref": "` + req.Ref + `"}`)}
+		}
+		return Response{Result: Result(`true`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	suite := TestSuite{
+		Name:           "Ordered",
+		ExecutionModel: ExecutionDependencyOrdered,
+		Tests: []TestCase{
+			{Request: Request{ID: "use_b", Method: "m", Params: json.RawMessage(`{"x": {"ref": "$b"}}`)}, ExpectedResponse: Response{Result: Result(`true`)}},
+			{Request: Request{ID: "create_a", Method: "m", Ref: "$a"}, ExpectedResponse: Response{Result: Result(`{"ref": "$a"}`)}},
+			// Fails, so its dependent use_b is skipped while the unrelated test still runs
+			{Request: Request{ID: "create_b", Method: "m", Params: json.RawMessage(`{"x": {"ref": "$a"}}`), Ref: "$b"}, ExpectedResponse: Response{Result: Result(`{"ref": "$b"}`)}},
+			{Request: Request{ID: "unrelated", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+		},
+	}
+
+	for i := range suite.Tests {
+		suite.Tests[i].Description = "Test " + suite.Tests[i].Request.ID
+	}
+
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+
+	if got := strings.Join(sent, ","); got != "create_a,unrelated,create_b" {
+		t.Errorf("unexpected requests sent: %s", got)
+	}
+	if result.PassedTests != 2 || result.FailedTests != 2 {
+		t.Fatalf("expected 2 passed and 2 failed, got %d and %d", result.PassedTests, result.FailedTests)
+	}
+	for _, tr := range result.TestResults {
+		if tr.TestID == "use_b" && tr.Message != "Skipped due to failure of dependency create_b" {
+			t.Errorf("unexpected message for skipped dependent: %q", tr.Message)
+		}
+		// Results are not in file order, so each must carry its own test's description
+		if tr.Description != "Test "+tr.TestID {
+			t.Errorf("result %s has description %q", tr.TestID, tr.Description)
+		}
+	}
+}
+
+func TestLoadTestSuiteFromFS_ExecutionModel(t *testing.T) {
+	fsys := fstest.MapFS{
+		"ordered.json": &fstest.MapFile{Data: []byte(`{"execution_model": "dependency_ordered", "tests": [
+			{"request": {"id": "1", "method": "m", "params": {"x": {"ref": "$a"}}}, "expected_response": {}},
+			{"request": {"id": "2", "method": "m", "ref": "$a"}, "expected_response": {}}
+		]}`)},
+		"unknown.json":   &fstest.MapFile{Data: []byte(`{"execution_model": "random", "tests": []}`)},
+		"undefined.json": &fstest.MapFile{Data: []byte(`{"execution_model": "dependency_ordered", "tests": [{"request": {"id": "1", "method": "m", "params": {"x": {"ref": "$missing"}}}, "expected_response": {}}]}`)},
+	}
+
+	if _, err := LoadTestSuiteFromFS(fsys, "ordered.json"); err != nil {
+		t.Errorf("failed to load dependency_ordered suite: %v", err)
+	}
+	if _, err := LoadTestSuiteFromFS(fsys, "unknown.json"); err == nil {
+		t.Errorf("expected error for unknown execution_model")
+	}
+	if _, err := LoadTestSuiteFromFS(fsys, "undefined.json"); err == nil {
+		t.Errorf("expected error for dependency_ordered suite using an undefined ref")
+	}
+}
+
+func TestTestRunner_SetLogger(t *testing.T) {
+	h, err := newHandlerForTest(t, helperNameCrash, 0)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+
+	var logs bytes.Buffer
+	tr := &TestRunner{handler: h}
+	tr.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	if err := tr.SendRequest(Request{ID: "1", Method: "m"}); err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	if _, err := tr.ReadResponse(); err == nil {
+		t.Fatal("Expected error from crashed handler")
+	}
+
+	// Both the runner and the handler it already spawned log to the injected logger
+	for _, want := range []string{"Failed to read response", "Handler exit with error"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("expected log output to contain %q, got:\n%s", want, logs.String())
+		}
+	}
+}
+
+func TestResult_IsSubsetOf(t *testing.T) {
+	tests := []struct {
+		sub, super string
+		want       bool
+	}{
+		{`{"a": 1}`, `{"a": 1.0, "b": 2}`, true},
+		{`{"a": 1, "c": 3}`, `{"a": 1, "b": 2}`, false},
+		{`{"a": {"x": true}}`, `{"a": {"x": true, "y": false}}`, true},
+		{`{"a": {"x": true}}`, `{"a": {"x": false}}`, false},
+		{`[{"id": 1}, {"id": 2}]`, `[{"id": 1, "n": "a"}, {"id": 2, "n": "b"}]`, true},
+		{`[{"id": 2}, {"id": 1}]`, `[{"id": 1}, {"id": 2}]`, false},
+		{`[1]`, `[1, 2]`, false},
+		{`{}`, `{"a": 1}`, true},
+		{`"abc"`, `"abc"`, true},
+		{`null`, `null`, true},
+		{`1`, `"1"`, false},
+		{`{"a": 1}`, `[1]`, false},
+		{`{"a": 1}`, `not json`, false},
+	}
+
+	for _, tt := range tests {
+		if got := Result(tt.sub).IsSubsetOf(Result(tt.super)); got != tt.want {
+			t.Errorf("Result(%s).IsSubsetOf(%s) = %v, want %v", tt.sub, tt.super, got, tt.want)
+		}
+	}
+}
+
+func TestValidateResponse_RequiredResultFields(t *testing.T) {
+	result := Result(`{"block": {"header": {"version": 1, "nonce": null}}}`)
+	tests := []struct {
+		fields  []string
+		wantErr string
+	}{
+		{fields: []string{"block", "block.header.version", "block.header.nonce"}},
+		{fields: []string{"block.header.version", "block.height"}, wantErr: "result missing required field: block.height"},
+		{fields: []string{"block.header.version.major"}, wantErr: "result missing required field: block.header.version.major"},
+	}
+
+	for _, tt := range tests {
+		test := &TestCase{
+			Request:              Request{ID: "1"},
+			ExpectedResponse:     Response{Result: result},
+			RequiredResultFields: tt.fields,
+		}
+		err := validateResponse(test, &Response{Result: result})
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("fields %v: expected no error, got %v", tt.fields, err)
+		case tt.wantErr != "" && (err == nil || err.Reason != ReasonMissingField || err.Message != tt.wantErr):
+			t.Errorf("fields %v: expected %q, got %v", tt.fields, tt.wantErr, err)
+		}
+	}
+}
+
+func TestRunTestSuite_Hooks(t *testing.T) {
+	var events []string
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		events = append(events, "request "+req.ID)
+		return Response{Result: Result(`true`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	hooks := func(id string, beforeErr error) (func(context.Context) error, func(context.Context)) {
+		before := func(context.Context) error {
+			events = append(events, "before "+id)
+			return beforeErr
+		}
+		after := func(context.Context) {
+			events = append(events, "after "+id)
+		}
+		return before, after
+	}
+	okBefore, okAfter := hooks("ok", nil)
+	failBefore, failAfter := hooks("fail", errors.New("setup failed"))
+
+	suite := TestSuite{
+		Name: "Hooks",
+		Tests: []TestCase{
+			{Request: Request{ID: "ok", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}, BeforeHook: okBefore, AfterHook: okAfter},
+			{Request: Request{ID: "fail", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}, BeforeHook: failBefore, AfterHook: failAfter},
+		},
+	}
+
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	if !result.TestResults[0].Passed {
+		t.Errorf("expected first test to pass, got %q", result.TestResults[0].Message)
+	}
+	if got := result.TestResults[1]; got.Passed || got.Message != "before hook failed: setup failed" {
+		t.Errorf("expected before hook failure, got passed=%v message=%q", got.Passed, got.Message)
+	}
+
+	want := []string{"before ok", "request ok", "after ok", "before fail", "after fail"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+func TestRunTestSuite_ResultRequest(t *testing.T) {
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		return Response{Result: Result(`false`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	suite := TestSuite{
+		Name:     "Request",
+		Stateful: true,
+		Tests: []TestCase{
+			{Request: Request{ID: "1", Method: "m", Params: json.RawMessage(`{"a":1}`)}, ExpectedResponse: Response{Result: Result(`true`)}},
+			{Request: Request{ID: "2", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+		},
+	}
+
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	got := result.TestResults[0]
+	if got.Request == nil || !reflect.DeepEqual(*got.Request, suite.Tests[0].Request) {
+		t.Errorf("expected result to carry the test request, got %+v", got.Request)
+	}
+	if got.ReceivedResponse == nil || string(got.ReceivedResponse.Result) != "false" {
+		t.Errorf("expected result to carry the received response, got %+v", got.ReceivedResponse)
+	}
+	if skipped := result.TestResults[1]; skipped.Request != nil {
+		t.Errorf("expected skipped test to carry no request, got %+v", skipped.Request)
+	}
+}
+
+func TestRunTestSuite_FailFast(t *testing.T) {
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		return Response{Result: Result(`true`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	suite := TestSuite{
+		Name: "FailFast",
+		Tests: []TestCase{
+			{Request: Request{ID: "1", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+			{Request: Request{ID: "2", Method: "m"}, ExpectedResponse: Response{Result: Result(`false`)}},
+			{Request: Request{ID: "3", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+		},
+	}
+
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	if result.TotalTests != 3 || result.FailedTests != 1 {
+		t.Errorf("expected all tests to run without fail-fast, got %d total, %d failed", result.TotalTests, result.FailedTests)
+	}
+
+	result = tr.RunTestSuite(context.Background(), suite, RunOptions{FailFast: true})
+	if result.TotalTests != 2 || result.PassedTests != 1 || result.FailedTests != 1 || len(result.TestResults) != 2 {
+		t.Errorf("expected suite to stop after the failed test, got %d total, %d passed, %d failed, %d results",
+			result.TotalTests, result.PassedTests, result.FailedTests, len(result.TestResults))
+	}
+}
+
+func TestRunTestSuite_DependencyCycle(t *testing.T) {
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		t.Errorf("unexpected request %s", req.ID)
+		return Response{}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	result := tr.RunTestSuite(context.Background(), TestSuite{
+		Name:     "Cycle",
+		Stateful: true,
+		Tests: []TestCase{
+			{Request: Request{ID: "a", Method: "m"}, DependsOn: []string{"b"}},
+			{Request: Request{ID: "b", Method: "m"}, DependsOn: []string{"a"}},
+		},
+	}, RunOptions{})
+	if !result.Skipped || result.SkipReason != "dependency cycle: a -> b -> a" {
+		t.Errorf("expected suite to be skipped for the cycle, got skipped=%v reason=%q", result.Skipped, result.SkipReason)
+	}
+}
+
+func TestRunTestSuite_ContinueOnFailure(t *testing.T) {
+	var sent []string
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		sent = append(sent, req.ID)
+		return Response{Result: Result(`true`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	for _, model := range []ExecutionModel{ExecutionSequential, ExecutionDependencyOrdered} {
+		sent = nil
+		suite := TestSuite{
+			Name:              "ContinueOnFailure",
+			ExecutionModel:    model,
+			ContinueOnFailure: true,
+			Tests: []TestCase{
+				{Request: Request{ID: "1", Method: "m", Ref: "$a"}, ExpectedResponse: Response{Result: Result(`false`)}},
+				{Request: Request{ID: "2", Method: "m", Params: json.RawMessage(`{"x": {"ref": "$a"}}`)}, ExpectedResponse: Response{Result: Result(`true`)}},
+			},
+		}
+
+		result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+		if got := strings.Join(sent, ","); got != "1,2" || result.PassedTests != 1 || result.FailedTests != 1 {
+			t.Errorf("%s: expected both tests to run, got requests %q, %d passed, %d failed",
+				model, got, result.PassedTests, result.FailedTests)
+		}
+	}
+}
+
+func TestTestRunner_Clone(t *testing.T) {
+	var mu sync.Mutex
+	handlers := 0
+	tr, err := NewTestRunnerWithHandler(func() (HandlerInterface, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		handlers++
+		return &inProcessHandler{fn: func(req Request) Response {
+			return Response{Result: Result(`true`)}
+		}}, nil
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create runner: %v", err)
+	}
+	defer tr.CloseHandler()
+	tr.SetEnforceSLOs(true)
+	tr.SetProgress(func(SingleTestResult) {})
+
+	clone := tr.Clone()
+	defer clone.CloseHandler()
+	if clone.handler != nil || !clone.enforceSLOs || clone.progress != nil {
+		t.Errorf("expected clone with options but without handler and progress, got %+v", clone)
+	}
+
+	suite := TestSuite{
+		Name:  "Clone",
+		Tests: []TestCase{{Request: Request{ID: "1", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}}},
+	}
+	var wg sync.WaitGroup
+	for _, r := range []*TestRunner{tr, clone} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result := r.RunTestSuite(context.Background(), suite, RunOptions{}); result.PassedTests != 1 {
+				t.Errorf("expected suite to pass, got %+v", result)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if handlers != 2 || clone.handler == tr.handler {
+		t.Errorf("expected the clone to spawn its own handler, got %d handlers", handlers)
+	}
+}
+
+func TestTestRunner_WithHandler(t *testing.T) {
+	// The handler process inherits the environment selecting the test helper
+	t.Setenv(envTestAsSubprocess, "1")
+	t.Setenv(envTestHelperName, helperNameLogging)
+
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		return Response{Result: Result(`false`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create runner: %v", err)
+	}
+	defer tr.CloseHandler()
+	tr.SetEnforceSLOs(true)
+
+	override := tr.WithHandler(os.Args[0], nil)
+	defer override.CloseHandler()
+	if override.handler != nil || !override.enforceSLOs || tr.handlerConfig != nil {
+		t.Errorf("expected a clone with options but without handler, leaving tr unchanged")
+	}
+
+	suite := TestSuite{
+		Name:  "Override",
+		Tests: []TestCase{{Request: Request{ID: "1", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}}},
+	}
+	if result := override.RunTestSuite(context.Background(), suite, RunOptions{}); result.PassedTests != 1 {
+		t.Errorf("expected the suite to run against the handler binary, got %+v", result)
+	}
+}
+
+func TestTestRunner_WithHandlerEnv(t *testing.T) {
+	tr := &TestRunner{handlerConfig: &HandlerConfig{Path: "handler", Env: make([]string, 1, 4)}}
+	tr.handlerConfig.Env[0] = "A=1"
+
+	clone := tr.WithHandlerEnv([]string{"B=2"})
+	if got := clone.handlerConfig.Env; !slices.Equal(got, []string{"A=1", "B=2"}) {
+		t.Errorf("expected clone env [A=1 B=2], got %v", got)
+	}
+	if got := tr.WithHandlerEnv([]string{"C=3"}).handlerConfig.Env; !slices.Equal(got, []string{"A=1", "C=3"}) {
+		t.Errorf("expected env of clones to be independent, got %v", got)
+	}
+	if got := tr.handlerConfig.Env; !slices.Equal(got, []string{"A=1"}) {
+		t.Errorf("expected tr env to be unchanged, got %v", got)
+	}
+
+	fsys := fstest.MapFS{"invalid.json": &fstest.MapFile{Data: []byte(`{"handler_env": ["regtest"], "tests": [
+		{"request": {"id": "1", "method": "m"}, "expected_response": {}}
+	]}`)}}
+	if _, err := LoadTestSuiteFromFS(fsys, "invalid.json"); err == nil {
+		t.Errorf("expected error for handler_env entry without '='")
+	}
+}
+
+func TestTestRunner_AcquireHandler(t *testing.T) {
+	cfg := &HandlerConfig{
+		Path: os.Args[0],
+		Env:  []string{"TEST_AS_SUBPROCESS=1", "TEST_HELPER_NAME=" + helperNameNormal},
+	}
+	tr := &TestRunner{handlerConfig: cfg, timeout: 30 * time.Second}
+	tr.newHandler = func() (HandlerInterface, error) { return NewHandler(cfg) }
+	pool, err := tr.NewHandlerPool(1)
+	if err != nil {
+		t.Fatalf("failed to create handler pool: %v", err)
+	}
+	defer pool.Close()
+
+	for _, restart := range []bool{false, true} {
+		release, err := tr.AcquireHandler(context.Background(), pool)
+		if err != nil {
+			t.Fatalf("failed to acquire handler: %v", err)
+		}
+		pooled := tr.handler.(*Handler)
+		if restart {
+			tr.restartHandler("test")
+			if err := tr.ensureHandler(); err != nil {
+				t.Fatalf("failed to spawn handler: %v", err)
+			}
+		}
+		replacement := tr.handler.(*Handler)
+		release()
+
+		if tr.handler != nil {
+			t.Errorf("expected release to leave the runner without handler")
+		}
+		if pooled.closed != restart || replacement.closed != restart {
+			t.Errorf("restart=%v: expected handlers closed only if replaced, got pooled=%v replacement=%v",
+				restart, pooled.closed, replacement.closed)
+		}
+	}
+
+	inProcess, err := NewTestRunnerInProcess(func(Request) Response { return Response{} }, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer inProcess.CloseHandler()
+	if pool, err := inProcess.NewHandlerPool(1); pool != nil || err != nil {
+		t.Errorf("expected no handler pool for in-process runner, got %v, %v", pool, err)
+	}
+}
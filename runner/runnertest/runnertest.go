@@ -0,0 +1,56 @@
+// Package runnertest runs conformance test suites from Go tests, so that projects can
+// make them part of their `go test` run. It is kept apart from package runner so that
+// the runner does not depend on package testing.
+package runnertest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// suiteTimeout is the total time RunSuite allows a suite to run
+const suiteTimeout = 30 * time.Second
+
+// RunSuite runs a test suite against the handler binary at handlerPath from a Go test,
+// so that conformance tests can be part of a project's `go test` run. The whole suite is
+// run first, so that stateful suites execute in order, and then each test case is
+// reported as a subtest of t named after its test ID, which fails with the test's
+// verbose output if the test failed. Disabled and skipped tests are reported as
+// skipped. As t.Run separates subtest names with '/', a '/' in a test ID is replaced
+// by '-'.
+func RunSuite(t *testing.T, handlerPath string, suite runner.TestSuite) {
+	t.Helper()
+
+	tr, err := runner.NewTestRunner(handlerPath, 0, suiteTimeout)
+	if err != nil {
+		t.Fatalf("failed to create test runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	ctx, cancel := context.WithTimeout(context.Background(), suiteTimeout)
+	defer cancel()
+	result := tr.RunTestSuite(ctx, suite, runner.RunOptions{Verbosity: runner.VerbosityOnFailure})
+
+	for _, testResult := range result.TestResults {
+		t.Run(subtestName(testResult.TestID), func(t *testing.T) {
+			if testResult.Disabled {
+				t.Skip("test is disabled")
+			}
+			if testResult.Skipped {
+				t.Skipf("test is skipped: %s", testResult.Message)
+			}
+			if !testResult.Passed {
+				t.Fatalf("%s", testResult.Message)
+			}
+		})
+	}
+}
+
+// subtestName returns the name of the subtest RunSuite reports a test as
+func subtestName(testID string) string {
+	return strings.ReplaceAll(testID, "/", "-")
+}
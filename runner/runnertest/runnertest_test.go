@@ -0,0 +1,43 @@
+package runnertest
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stringintech/kernel-bindings-tests/runner"
+)
+
+// envTestAsHandler makes the test binary act as a handler answering every request with
+// true, so that tests can spawn it as a subprocess
+const envTestAsHandler = "TEST_AS_HANDLER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(envTestAsHandler) != "1" {
+		os.Exit(m.Run())
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fmt.Println(`{"result":true}`)
+	}
+}
+
+func TestRunSuite(t *testing.T) {
+	// The handler process inherits the environment
+	t.Setenv(envTestAsHandler, "1")
+
+	RunSuite(t, os.Args[0], runner.TestSuite{
+		Name: "Go test",
+		Tests: []runner.TestCase{
+			{Request: runner.Request{ID: "first/1", Method: "m"}, ExpectedResponse: runner.Response{Result: runner.Result(`true`)}},
+			{Request: runner.Request{ID: "second", Method: "m"}, ExpectedResponse: runner.Response{Result: runner.Result(`false`)}, Disabled: true},
+		},
+	})
+}
+
+func TestSubtestName(t *testing.T) {
+	if got := subtestName("chain/block#1"); got != "chain-block#1" {
+		t.Errorf("expected chain-block#1, got %q", got)
+	}
+}
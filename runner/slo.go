@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"time"
+)
+
+// SetEnforceSLOs controls whether a test that exceeds its ExpectedResponseTime fails.
+// Otherwise, the violation is only logged as a warning. Defaults to false.
+func (tr *TestRunner) SetEnforceSLOs(enforce bool) {
+	tr.enforceSLOs = enforce
+}
+
+// ExpectedResponseTimeValue returns the parsed ExpectedResponseTime, or zero if it is
+// unset or invalid. LoadTestSuiteFromFS rejects suites with an invalid response time.
+func (tc *TestCase) ExpectedResponseTimeValue() time.Duration {
+	d, err := time.ParseDuration(tc.ExpectedResponseTime)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// checkSLO compares the duration of a passed test against its ExpectedResponseTime,
+// failing the test on a violation if SLOs are enforced
+func (tr *TestRunner) checkSLO(suite *TestSuite, test *TestCase, result *SingleTestResult) {
+	slo := test.ExpectedResponseTimeValue()
+	if slo == 0 || !result.Passed || result.Duration <= slo {
+		return
+	}
+	if !tr.enforceSLOs {
+		tr.log().Warn("SLO exceeded", "suite", suite.Name, "test", test.Request.ID,
+			"duration", result.Duration, "expected_response_time", slo)
+		return
+	}
+	result.Passed = false
+	result.ValidationError = newValidationError(ReasonSLOExceeded, test, "responded in %v, expected at most %v", result.Duration, slo)
+	result.Message = "SLO exceeded: " + result.ValidationError.Message
+}
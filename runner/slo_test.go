@@ -0,0 +1,86 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRunTestSuite_ExpectedResponseTime(t *testing.T) {
+	tests := []struct {
+		name       string
+		enforce    bool
+		wantPassed bool
+	}{
+		{name: "informational", enforce: false, wantPassed: true},
+		{name: "enforced", enforce: true, wantPassed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, err := NewTestRunnerInProcess(func(req Request) Response {
+				if req.Method == "slow" {
+					time.Sleep(20 * time.Millisecond)
+				}
+				return Response{Result: Result(`true`)}
+			}, 0)
+			if err != nil {
+				t.Fatalf("failed to create in-process runner: %v", err)
+			}
+			defer tr.CloseHandler()
+
+			var logs bytes.Buffer
+			tr.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+			tr.SetEnforceSLOs(tt.enforce)
+
+			suite := TestSuite{
+				Name: "SLO",
+				Tests: []TestCase{
+					{Request: Request{ID: "fast", Method: "fast"}, ExpectedResponse: Response{Result: Result(`true`)}, ExpectedResponseTime: "10s"},
+					{Request: Request{ID: "slow", Method: "slow"}, ExpectedResponse: Response{Result: Result(`true`)}, ExpectedResponseTime: "1ms"},
+				},
+			}
+
+			result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+			if !result.TestResults[0].Passed {
+				t.Errorf("expected fast test to pass, got %q", result.TestResults[0].Message)
+			}
+			slow := result.TestResults[1]
+			if slow.Passed != tt.wantPassed {
+				t.Fatalf("expected slow test passed=%v, got %v (%s)", tt.wantPassed, slow.Passed, slow.Message)
+			}
+			if tt.enforce && !strings.Contains(slow.Message, "SLO exceeded") {
+				t.Errorf("unexpected message: %q", slow.Message)
+			}
+			if warned := strings.Contains(logs.String(), "SLO exceeded"); warned == tt.enforce {
+				t.Errorf("expected warning=%v, got log output:\n%s", !tt.enforce, logs.String())
+			}
+		})
+	}
+}
+
+func TestLoadTestSuiteFromFS_ExpectedResponseTime(t *testing.T) {
+	fsys := fstest.MapFS{
+		"valid.json": &fstest.MapFile{Data: []byte(`{"tests": [
+			{"request": {"id": "1", "method": "m"}, "expected_response": {}, "expected_response_time": "50ms"}
+		]}`)},
+		"invalid.json": &fstest.MapFile{Data: []byte(`{"tests": [
+			{"request": {"id": "1", "method": "m"}, "expected_response": {}, "expected_response_time": "fast"}
+		]}`)},
+	}
+
+	suite, err := LoadTestSuiteFromFS(fsys, "valid.json")
+	if err != nil {
+		t.Fatalf("failed to load valid suite: %v", err)
+	}
+	if got := suite.Tests[0].ExpectedResponseTimeValue(); got != 50*time.Millisecond {
+		t.Errorf("expected 50ms, got %v", got)
+	}
+	if _, err := LoadTestSuiteFromFS(fsys, "invalid.json"); err == nil {
+		t.Error("expected error for invalid expected_response_time")
+	}
+}
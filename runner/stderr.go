@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"bufio"
+	"io"
+	"sync"
+)
+
+// stderrBufferLines is the number of most recent stderr lines kept for each handler
+const stderrBufferLines = 256
+
+// stderrBuffer is a ring buffer of the most recent lines a handler wrote to stderr. It
+// is safe for concurrent use.
+type stderrBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	total int // Number of lines written since the handler started
+}
+
+// capture reads r line by line into the buffer until r is exhausted, then closes done
+func (b *stderrBuffer) capture(r io.Reader, done chan<- struct{}) {
+	defer close(done)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, MaxLineSize)
+	for scanner.Scan() {
+		b.add(scanner.Text())
+	}
+}
+
+// add appends a line, dropping the oldest line once the buffer is full
+func (b *stderrBuffer) add(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.lines) < stderrBufferLines {
+		b.lines = append(b.lines, line)
+	} else {
+		b.lines[b.total%stderrBufferLines] = line
+	}
+	b.total++
+}
+
+// since returns the buffered lines, oldest first, that were written after the first n
+// lines, together with the total number of lines written so far. Lines that have
+// already been dropped from the buffer are not returned.
+func (b *stderrBuffer) since(n int) ([]string, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	first := min(max(n, b.total-len(b.lines)), b.total)
+	lines := make([]string, 0, b.total-first)
+	for i := first; i < b.total; i++ {
+		lines = append(lines, b.lines[i%stderrBufferLines])
+	}
+	return lines, b.total
+}
@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStderrBuffer_Since(t *testing.T) {
+	var b stderrBuffer
+	if lines, total := b.since(0); len(lines) != 0 || total != 0 {
+		t.Fatalf("expected empty buffer, got %v (total %d)", lines, total)
+	}
+
+	for i := range 3 {
+		b.add(fmt.Sprintf("line %d", i))
+	}
+	if lines, total := b.since(1); !slices.Equal(lines, []string{"line 1", "line 2"}) || total != 3 {
+		t.Errorf("since(1) = %v, %d", lines, total)
+	}
+
+	// Overflow the buffer; only the most recent lines are kept
+	for i := 3; i < stderrBufferLines+5; i++ {
+		b.add(fmt.Sprintf("line %d", i))
+	}
+	lines, total := b.since(0)
+	if total != stderrBufferLines+5 || len(lines) != stderrBufferLines {
+		t.Fatalf("expected %d of %d lines, got %d of %d", stderrBufferLines, stderrBufferLines+5, len(lines), total)
+	}
+	if lines[0] != "line 5" || lines[len(lines)-1] != fmt.Sprintf("line %d", stderrBufferLines+4) {
+		t.Errorf("unexpected oldest/newest lines: %q, %q", lines[0], lines[len(lines)-1])
+	}
+	if lines, _ := b.since(total - 2); strings.Join(lines, ",") != fmt.Sprintf("line %d,line %d", total-2, total-1) {
+		t.Errorf("unexpected recent lines: %v", lines)
+	}
+}
@@ -0,0 +1,173 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SetStreaming controls whether the runner multiplexes requests (see
+// HandlerConfig.Streaming).
+func (tr *TestRunner) SetStreaming(streaming bool) {
+	if tr.handlerConfig == nil {
+		tr.handlerConfig = &HandlerConfig{}
+	}
+	tr.handlerConfig.Streaming = streaming
+}
+
+// streamedResponse is a response received in streaming mode, together with the request
+// ID it answers and the time it was received
+type streamedResponse struct {
+	ID *string `json:"id"`
+	Response
+	receivedAt time.Time
+}
+
+// canStream reports whether the tests of a suite can be streamed: streaming is enabled,
+// the tests are independent of each other, of per-test hooks and of retries, their IDs
+// are unique, and the handler is a subprocess that can be written to and read from
+// concurrently. The handler is spawned if needed.
+func (tr *TestRunner) canStream(suite *TestSuite, groups *testGroups) bool {
+	if tr.handlerConfig == nil || !tr.handlerConfig.Streaming {
+		return false
+	}
+	if suite.Model() != ExecutionParallel || suite.BeforeEachTest != nil || suite.AfterEachTest != nil || groups != nil {
+		return false
+	}
+
+	seen := make(map[string]bool, len(suite.Tests))
+	for _, test := range suite.Tests {
+		if seen[test.Request.ID] || test.BeforeHook != nil || test.AfterHook != nil || test.RetryCount > 0 || len(test.DependsOn) > 0 {
+			return false
+		}
+		seen[test.Request.ID] = true
+	}
+
+	if err := tr.ensureHandler(); err != nil {
+		// Let the regular path report the spawn failure for every test
+		return false
+	}
+	_, ok := tr.handler.(*Handler)
+	return ok
+}
+
+// streamTests runs the enabled tests at the given indices by sending all requests
+// without waiting for responses, while a receiver goroutine correlates the responses,
+// which may arrive in any order, to their requests by ID. Responses must carry the
+// request ID. Returns the result of every enabled test by index.
+//
+// As the handler processes requests concurrently, per-test restart policies cannot
+// apply: the handler is restarted at most once, after all responses were received.
+func (tr *TestRunner) streamTests(ctx context.Context, suite *TestSuite, order []int) map[int]SingleTestResult {
+	results := make(map[int]SingleTestResult)
+	var indices []int
+	for _, i := range order {
+		if !suite.Tests[i].Disabled && !suite.Tests[i].Skip {
+			indices = append(indices, i)
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		for _, i := range indices {
+			results[i] = SingleTestResult{
+				TestID:  suite.Tests[i].Request.ID,
+				Message: tr.timeoutMessage(ctx),
+			}
+		}
+		return results
+	default:
+	}
+
+	pending := make(map[string]chan streamedResponse, len(indices))
+	for _, i := range indices {
+		pending[suite.Tests[i].Request.ID] = make(chan streamedResponse, 1)
+	}
+
+	stderrMark := tr.stderrMark()
+	handler := tr.handler
+	recvDone := make(chan error, 1)
+	go func() {
+		recvDone <- tr.receiveStreamed(handler, pending)
+	}()
+
+	// Send all requests, recording when each was sent
+	sentAt := make(map[int]time.Time, len(indices))
+	var sendErr error
+	for _, i := range indices {
+		req := suite.Tests[i].Request
+		reqData, err := marshalRequest(req, tr.handlerConfig.EscapeHTML)
+		if err == nil {
+			tr.logRequest(req)
+			sentAt[i] = time.Now()
+			err = handler.SendLine(reqData)
+		}
+		if err != nil {
+			// The receiver fails once the broken handler's stdout closes
+			sendErr = fmt.Errorf("failed to write request: %w", err)
+			break
+		}
+	}
+
+	recvErr := <-recvDone
+	failed := false
+	for _, i := range indices {
+		test := &suite.Tests[i]
+		var result SingleTestResult
+		select {
+		case resp := <-pending[test.Request.ID]:
+			result = tr.checkResponse(test, &resp.Response, stderrMark)
+			result.Duration = resp.receivedAt.Sub(sentAt[i])
+		default:
+			if sentAt[i].IsZero() {
+				result = SingleTestResult{TestID: test.Request.ID, Message: fmt.Sprintf("Failed to send request: %v", sendErr)}
+			} else {
+				result = SingleTestResult{TestID: test.Request.ID, Message: fmt.Sprintf("Failed to read response: %v", recvErr)}
+			}
+		}
+		failed = failed || !result.Passed
+		results[i] = result
+	}
+
+	switch {
+	case sendErr != nil || recvErr != nil:
+		tr.log().Warn("Streaming failed, cleaning up handler (will spawn new one for remaining tests)", "send_error", sendErr, "receive_error", recvErr)
+		tr.handlerRestarts++
+		tr.CloseHandler()
+	case tr.restartPolicy == RestartOnFailure && failed:
+		tr.restartHandler(fmt.Sprintf("streamed tests of suite %s failed", suite.Name))
+	case tr.restartPolicy == RestartAlways:
+		tr.restartHandler(fmt.Sprintf("streamed tests of suite %s finished", suite.Name))
+	}
+	return results
+}
+
+// receiveStreamed reads responses until every pending request has been answered,
+// delivering each response to the channel of its request ID. Returns an error if
+// reading fails or a response cannot be correlated to a pending request.
+func (tr *TestRunner) receiveStreamed(handler HandlerInterface, pending map[string]chan streamedResponse) error {
+	answered := make(map[string]bool, len(pending))
+	for len(answered) < len(pending) {
+		line, err := handler.ReadLine()
+		if err != nil {
+			return err
+		}
+		resp := streamedResponse{receivedAt: time.Now()}
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return fmt.Errorf("invalid response %s: %w", line, err)
+		}
+		tr.logResponse(&resp.Response)
+
+		if resp.ID == nil {
+			return fmt.Errorf("response without id in streaming mode: %s", line)
+		}
+		ch, ok := pending[*resp.ID]
+		if !ok || answered[*resp.ID] {
+			return fmt.Errorf("response id %q does not match a pending request", *resp.ID)
+		}
+		answered[*resp.ID] = true
+		ch <- resp
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunTestSuite_Streaming(t *testing.T) {
+	newSuite := func() TestSuite {
+		test := func(id, value, expected string) TestCase {
+			return TestCase{
+				Request:          Request{ID: id, Method: "m", Params: json.RawMessage(`{"value": ` + value + `}`)},
+				ExpectedResponse: Response{Result: Result(expected)},
+			}
+		}
+		return TestSuite{
+			Name: "Streamed",
+			Tests: []TestCase{
+				test("1", "1", "1"),
+				test("2", "2", "2"),
+				test("3", "3", "4"),
+				test("4", "4", "4"),
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		env         string
+		wantPassed  int
+		wantMessage string
+	}{
+		{
+			name:        "out of order responses",
+			wantPassed:  3,
+			wantMessage: "Invalid response",
+		},
+		{
+			name:        "responses without id",
+			env:         "CONCURRENT_NO_ID=1",
+			wantPassed:  0,
+			wantMessage: "response without id in streaming mode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := []string{"TEST_AS_SUBPROCESS=1", "TEST_HELPER_NAME=" + helperNameConcurrent}
+			if tt.env != "" {
+				env = append(env, tt.env)
+			}
+			cfg := &HandlerConfig{Path: os.Args[0], Env: env, Streaming: true}
+			h, err := NewHandler(cfg)
+			if err != nil {
+				t.Fatalf("Failed to create handler: %v", err)
+			}
+			tr := &TestRunner{
+				handler:       h,
+				handlerConfig: cfg,
+				newHandler:    func() (HandlerInterface, error) { return NewHandler(cfg) },
+			}
+			defer tr.CloseHandler()
+
+			result := tr.RunTestSuite(context.Background(), newSuite(), RunOptions{})
+			if result.PassedTests != tt.wantPassed {
+				t.Fatalf("expected %d passed tests, got %d: %+v", tt.wantPassed, result.PassedTests, result.TestResults)
+			}
+			for i, testResult := range result.TestResults {
+				if want := fmt.Sprint(i + 1); testResult.TestID != want {
+					t.Errorf("expected results in file order, got %s at %d", testResult.TestID, i)
+				}
+			}
+			if msg := result.TestResults[2].Message; !strings.Contains(msg, tt.wantMessage) {
+				t.Errorf("expected message to contain %q, got %q", tt.wantMessage, msg)
+			}
+		})
+	}
+}
+
+func TestRunTestSuite_StreamingInProcessFallback(t *testing.T) {
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		return Response{Result: Result(`true`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+	tr.SetStreaming(true)
+
+	suite := TestSuite{
+		Name: "Fallback",
+		Tests: []TestCase{
+			{Request: Request{ID: "1", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+			{Request: Request{ID: "2", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+		},
+	}
+	if result := tr.RunTestSuite(context.Background(), suite, RunOptions{}); result.PassedTests != 2 {
+		t.Errorf("expected in-process handler to run tests one by one, got %+v", result.TestResults)
+	}
+}
+
+// helperConcurrent simulates a handler that processes requests concurrently: it
+// answers every pair of requests in reverse order, with the value param as the result.
+// If CONCURRENT_NO_ID is set, responses do not carry the request id.
+func helperConcurrent() {
+	omitID := os.Getenv("CONCURRENT_NO_ID") != ""
+	var held []Request
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req Request
+		json.Unmarshal(scanner.Bytes(), &req)
+		held = append(held, req)
+		if len(held) < 2 {
+			continue
+		}
+		for i := len(held) - 1; i >= 0; i-- {
+			var params struct {
+				Value json.RawMessage `json:"value"`
+			}
+			json.Unmarshal(held[i].Params, &params)
+			if omitID {
+				fmt.Printf(`{"result":%s}`+"\n", params.Value)
+			} else {
+				fmt.Printf(`{"id":%q,"result":%s}`+"\n", held[i].ID, params.Value)
+			}
+		}
+		held = held[:0]
+	}
+}
@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// suiteLogEntry is a single line of a suite log file (see TestSuite.LogFile). Exactly one
+// of Request and Response is set; their fields are inlined next to the type.
+type suiteLogEntry struct {
+	Type string `json:"type"` // "request" or "response"
+	*Request
+	*Response
+}
+
+// SetAppendLogs controls whether suite log files are appended to instead of truncated
+// when a suite is run. Defaults to false.
+func (tr *TestRunner) SetAppendLogs(appendLogs bool) {
+	tr.appendLogs = appendLogs
+}
+
+// openSuiteLog opens the log file of a suite and directs request/response logging to it
+// until the returned close function is called
+func (tr *TestRunner) openSuiteLog(path string) (func() error, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if tr.appendLogs {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open suite log file: %w", err)
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	tr.suiteLog = enc
+	return func() error {
+		tr.suiteLog = nil
+		return f.Close()
+	}, nil
+}
+
+// logRequest writes a request to the suite log file and artifact directory, if open
+func (tr *TestRunner) logRequest(req Request) {
+	tr.logMu.Lock()
+	defer tr.logMu.Unlock()
+	if tr.suiteLog != nil {
+		_ = tr.suiteLog.Encode(suiteLogEntry{Type: "request", Request: &req})
+	}
+	if tr.artifacts != nil {
+		_ = tr.artifacts.reqEnc.Encode(req)
+	}
+}
+
+// logResponse writes a response to the suite log file and artifact directory, if open
+func (tr *TestRunner) logResponse(resp *Response) {
+	tr.logMu.Lock()
+	defer tr.logMu.Unlock()
+	if tr.suiteLog != nil {
+		_ = tr.suiteLog.Encode(suiteLogEntry{Type: "response", Response: resp})
+	}
+	if tr.artifacts != nil {
+		_ = tr.artifacts.respEnc.Encode(resp)
+	}
+}
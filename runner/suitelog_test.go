@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunTestSuite_LogFile(t *testing.T) {
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		return Response{Result: Result(`true`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	logPath := filepath.Join(t.TempDir(), "suite.ndjson")
+	suite := TestSuite{
+		Name:    "Logged",
+		LogFile: logPath,
+		Tests: []TestCase{
+			{Request: Request{ID: "1", Method: "m", Params: []byte(`{"a":1}`)}, ExpectedResponse: Response{Result: Result(`true`)}},
+		},
+	}
+
+	readLines := func() []string {
+		t.Helper()
+		data, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatalf("failed to read log file: %v", err)
+		}
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	tr.RunTestSuite(context.Background(), suite, RunOptions{})
+
+	lines := readLines()
+	want := []string{
+		`{"type":"request","id":"1","method":"m","params":{"a":1}}`,
+		`{"type":"response","result":true}`,
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected truncated log:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(lines, "\n"))
+	}
+
+	tr.SetAppendLogs(true)
+	tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	if lines := readLines(); len(lines) != 4 {
+		t.Errorf("expected appended log to have 4 lines, got %d", len(lines))
+	}
+
+	// Requests outside a logged suite are not written
+	tr.RunTestSuite(context.Background(), TestSuite{Name: "Unlogged", Tests: suite.Tests}, RunOptions{})
+	if lines := readLines(); len(lines) != 4 {
+		t.Errorf("expected log to be unchanged by unlogged suite, got %d lines", len(lines))
+	}
+}
@@ -0,0 +1,31 @@
+package runner
+
+import (
+	"fmt"
+)
+
+// sendSuiteRequest sends a suite-level request (see TestSuite.BeforeSuiteRequest and
+// TestSuite.BeforeEachTest) and reads its response. The response content is not
+// compared against anything; an error is returned only if the exchange fails, the
+// result is not valid JSON, or the handler returns an error response.
+func (tr *TestRunner) sendSuiteRequest(req *Request) error {
+	if err := tr.SendRequest(*req); err != nil {
+		return err
+	}
+	resp, err := tr.ReadResponse()
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if !resp.Result.IsNullOrOmitted() {
+		if err := validateJSONStructure(resp.Result); err != nil {
+			return err
+		}
+	}
+	if resp.Error != nil {
+		if resp.Error.Code != nil {
+			return fmt.Errorf("handler returned error %s.%s", resp.Error.Code.Type, resp.Error.Code.Member)
+		}
+		return fmt.Errorf("handler returned an error")
+	}
+	return nil
+}
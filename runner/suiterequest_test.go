@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRunTestSuite_SuiteRequests(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupFails  bool
+		wantSkipped bool
+		wantMethods []string
+	}{
+		{
+			name:        "setup succeeds",
+			wantMethods: []string{"setup", "m", "teardown"},
+		},
+		{
+			name:        "setup fails",
+			setupFails:  true,
+			wantSkipped: true,
+			wantMethods: []string{"setup", "teardown"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var methods []string
+			tr, err := NewTestRunnerInProcess(func(req Request) Response {
+				methods = append(methods, req.Method)
+				if req.Method == "setup" && tt.setupFails {
+					return Response{Error: &Error{Code: &ErrorCode{Type: "Handler", Member: "SETUP_FAILED"}}}
+				}
+				// The teardown result is not compared against anything
+				if req.Method == "teardown" {
+					return Response{Result: Result(`{"released": 3}`)}
+				}
+				return Response{Result: Result(`true`)}
+			}, 0)
+			if err != nil {
+				t.Fatalf("failed to create in-process runner: %v", err)
+			}
+			defer tr.CloseHandler()
+
+			suite := TestSuite{
+				Name:               "Hooks",
+				BeforeSuiteRequest: &Request{ID: "before", Method: "setup"},
+				AfterSuiteRequest:  &Request{ID: "after", Method: "teardown"},
+				Tests: []TestCase{
+					{Request: Request{ID: "1", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+				},
+			}
+
+			result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+			if result.Skipped != tt.wantSkipped {
+				t.Fatalf("expected skipped=%v, got %v (%s)", tt.wantSkipped, result.Skipped, result.SkipReason)
+			}
+			if tt.wantSkipped && !strings.Contains(result.SkipReason, "before_suite_request failed: handler returned error Handler.SETUP_FAILED") {
+				t.Errorf("unexpected skip reason: %q", result.SkipReason)
+			}
+			if !tt.wantSkipped && result.PassedTests != 1 {
+				t.Errorf("expected test to pass, got %+v", result.TestResults)
+			}
+			if strings.Join(methods, ",") != strings.Join(tt.wantMethods, ",") {
+				t.Errorf("expected methods %v, got %v", tt.wantMethods, methods)
+			}
+		})
+	}
+}
+
+func TestRunTestSuite_EachTestRequests(t *testing.T) {
+	var methods []string
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		methods = append(methods, req.Method)
+		// Resetting fails right before the second test
+		if req.Method == "reset" && len(methods) == 4 {
+			return Response{Error: &Error{Code: &ErrorCode{Type: "Handler", Member: "RESET_FAILED"}}}
+		}
+		return Response{Result: Result(`true`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	suite := TestSuite{
+		Name:           "Each",
+		BeforeEachTest: &Request{ID: "before", Method: "reset"},
+		AfterEachTest:  &Request{ID: "after", Method: "cleanup"},
+		Tests: []TestCase{
+			{Request: Request{ID: "1", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+			{Request: Request{ID: "2", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+			{Request: Request{ID: "3", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+		},
+	}
+
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	if want := "reset,m,cleanup,reset,reset,m,cleanup"; strings.Join(methods, ",") != want {
+		t.Errorf("expected methods %s, got %s", want, strings.Join(methods, ","))
+	}
+	if result.TotalTests != 2 || result.PassedTests != 2 || result.FailedTests != 0 || result.SkippedTests != 1 {
+		t.Fatalf("expected 2 passed and 1 skipped, got total=%d passed=%d failed=%d skipped=%d",
+			result.TotalTests, result.PassedTests, result.FailedTests, result.SkippedTests)
+	}
+	skipped := result.TestResults[1]
+	if !skipped.Skipped || !strings.Contains(skipped.Message, "before_each_test failed: handler returned error Handler.RESET_FAILED") {
+		t.Errorf("unexpected result for skipped test: %+v", skipped)
+	}
+}
@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// GenerateTestID returns a deterministic test ID of the form <method>_<hash>, where
+// hash is the first 8 hex characters of the SHA-256 digest of the canonical params JSON.
+// Params that differ only in formatting or key order produce the same ID.
+func GenerateTestID(method string, params json.RawMessage) string {
+	sum := sha256.Sum256([]byte(canonicalParams(params)))
+	return method + "_" + hex.EncodeToString(sum[:])[:8]
+}
+
+// Fingerprint returns a stable identifier for the test's request: the first 12 hex
+// characters of the SHA-256 digest of the method followed by the canonical params JSON.
+// Unlike the test ID, the fingerprint does not change when a test is renamed, so it can
+// be used to track the outcome of a test across runs.
+func (tc TestCase) Fingerprint() string {
+	sum := sha256.Sum256([]byte(tc.Request.Method + canonicalParams(tc.Request.Params)))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// canonicalParams returns params normalized via Result.Normalize. Omitted params are
+// treated as JSON null, and params that fail to parse are returned as-is.
+func canonicalParams(params json.RawMessage) string {
+	if len(params) == 0 {
+		return "null"
+	}
+	normalized, err := Result(params).Normalize()
+	if err != nil {
+		return string(params)
+	}
+	return normalized
+}
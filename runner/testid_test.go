@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+)
+
+func TestGenerateTestID(t *testing.T) {
+	id := GenerateTestID("btck_chain_get_height", json.RawMessage(`{"chain": {"ref": "$chain"}}`))
+	if !regexp.MustCompile(`^btck_chain_get_height_[0-9a-f]{8}$`).MatchString(id) {
+		t.Fatalf("unexpected ID format: %s", id)
+	}
+
+	// Formatting and key order must not affect the ID
+	a := GenerateTestID("m", json.RawMessage(`{"a": 1, "b": [1, 2]}`))
+	b := GenerateTestID("m", json.RawMessage(`{"b":[1,2],"a":1}`))
+	if a != b {
+		t.Errorf("expected equal IDs for equivalent params, got %s and %s", a, b)
+	}
+
+	// Omitted params are equivalent to null
+	if GenerateTestID("m", nil) != GenerateTestID("m", json.RawMessage(`null`)) {
+		t.Errorf("expected omitted params to be equivalent to null")
+	}
+
+	if GenerateTestID("m", json.RawMessage(`{"a": 1}`)) == GenerateTestID("m", json.RawMessage(`{"a": 2}`)) {
+		t.Errorf("expected different IDs for different params")
+	}
+	if GenerateTestID("m1", nil) == GenerateTestID("m2", nil) {
+		t.Errorf("expected different IDs for different methods")
+	}
+}
+
+func TestTestCase_Fingerprint(t *testing.T) {
+	tc := TestCase{Request: Request{ID: "a", Method: "m", Params: json.RawMessage(`{"a": 1, "b": 2}`)}}
+	fp := tc.Fingerprint()
+	if !regexp.MustCompile(`^[0-9a-f]{12}$`).MatchString(fp) {
+		t.Fatalf("unexpected fingerprint format: %s", fp)
+	}
+
+	// Renaming the test or reformatting its params must not change the fingerprint
+	renamed := TestCase{Request: Request{ID: "b", Method: "m", Params: json.RawMessage(`{"b":2,"a":1}`)}}
+	if renamed.Fingerprint() != fp {
+		t.Errorf("expected equal fingerprints, got %s and %s", fp, renamed.Fingerprint())
+	}
+
+	otherMethod := TestCase{Request: Request{ID: "a", Method: "n", Params: json.RawMessage(`{"a": 1, "b": 2}`)}}
+	if otherMethod.Fingerprint() == fp {
+		t.Errorf("expected different fingerprints for different methods")
+	}
+}
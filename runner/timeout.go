@@ -0,0 +1,57 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errSuiteTimeout is the cause of the context of a suite run that exceeded the suite's
+// TimeoutSeconds
+var errSuiteTimeout = errors.New("suite timeout exceeded")
+
+// TimeoutValue returns TimeoutMs as a duration
+func (tc *TestCase) TimeoutValue() time.Duration {
+	return time.Duration(tc.TimeoutMs) * time.Millisecond
+}
+
+// TimeoutValue returns TimeoutSeconds as a duration
+func (s *TestSuite) TimeoutValue() time.Duration {
+	return time.Duration(s.TimeoutSeconds) * time.Second
+}
+
+// withSuiteTimeout returns a context for running the suite that is cancelled once the
+// suite's timeout is exceeded, if it has one
+func withSuiteTimeout(ctx context.Context, suite *TestSuite) (context.Context, context.CancelFunc) {
+	if suite.TimeoutSeconds <= 0 {
+		return ctx, func() {}
+	}
+	timeout := suite.TimeoutValue()
+	return context.WithTimeoutCause(ctx, timeout, fmt.Errorf("%w (%v)", errSuiteTimeout, timeout))
+}
+
+// timeoutMessage describes why the context of a test run is done: the suite timeout
+// if it was exceeded, and the total execution timeout otherwise
+func (tr *TestRunner) timeoutMessage(ctx context.Context) string {
+	if cause := context.Cause(ctx); errors.Is(cause, errSuiteTimeout) {
+		return cause.Error()
+	}
+	return fmt.Sprintf("Total execution timeout exceeded (%v)", tr.timeout)
+}
+
+// overrideHandlerTimeout sets the read timeout of the handler, which is spawned if
+// needed, until the returned function is called. Handlers that do not run as a
+// subprocess have no timeout and are left alone.
+func (tr *TestRunner) overrideHandlerTimeout(timeout time.Duration) (restore func()) {
+	if err := tr.ensureHandler(); err != nil {
+		// Let SendRequest report the spawn failure
+		return func() {}
+	}
+	h, ok := tr.handler.(*Handler)
+	if !ok {
+		return func() {}
+	}
+	previous := h.setTimeout(timeout)
+	return func() { h.setTimeout(previous) }
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRunTest_TimeoutMs(t *testing.T) {
+	h, err := newHandlerForTest(t, helperNameUnresponsive, 10*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	tr := &TestRunner{handler: h}
+	defer tr.CloseHandler()
+
+	start := time.Now()
+	result := tr.runTest(context.Background(), &TestCase{Request: Request{ID: "1", Method: "test"}, TimeoutMs: 100})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the per-test timeout to override the handler timeout, took %v", elapsed)
+	}
+	if want := "Test timeout exceeded: no response within timeout_ms (100ms)"; result.Passed || result.Message != want {
+		t.Errorf("expected message %q, got passed=%v message=%q", want, result.Passed, result.Message)
+	}
+}
+
+func TestLoadTestSuiteFromFS_TimeoutMs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"valid.json": &fstest.MapFile{Data: []byte(`{"tests": [
+			{"request": {"id": "1", "method": "m"}, "expected_response": {}, "timeout_ms": 500}
+		]}`)},
+		"negative.json": &fstest.MapFile{Data: []byte(`{"tests": [
+			{"request": {"id": "1", "method": "m"}, "expected_response": {}, "timeout_ms": -1}
+		]}`)},
+		"negative_suite.json": &fstest.MapFile{Data: []byte(`{"timeout_seconds": -1, "tests": [
+			{"request": {"id": "1", "method": "m"}, "expected_response": {}}
+		]}`)},
+	}
+
+	suite, err := LoadTestSuiteFromFS(fsys, "valid.json")
+	if err != nil {
+		t.Fatalf("failed to load suite with timeout_ms: %v", err)
+	}
+	if got := suite.Tests[0].TimeoutValue(); got != 500*time.Millisecond {
+		t.Errorf("TimeoutValue() = %v, want 500ms", got)
+	}
+	if _, err := LoadTestSuiteFromFS(fsys, "negative.json"); err == nil {
+		t.Errorf("expected error for negative timeout_ms")
+	}
+	if _, err := LoadTestSuiteFromFS(fsys, "negative_suite.json"); err == nil {
+		t.Errorf("expected error for negative timeout_seconds")
+	}
+}
+
+func TestRunTestSuite_TimeoutSeconds(t *testing.T) {
+	h, err := newHandlerForTest(t, helperNameUnresponsive, 1500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	tr := &TestRunner{handler: h, timeout: time.Minute}
+	defer tr.CloseHandler()
+
+	result := tr.RunTestSuite(context.Background(), TestSuite{
+		Name:           "Suite timeout",
+		TimeoutSeconds: 1,
+		Tests: []TestCase{
+			{Request: Request{ID: "1", Method: "test"}},
+			{Request: Request{ID: "2", Method: "test"}},
+		},
+	}, RunOptions{})
+
+	if len(result.TestResults) != 2 {
+		t.Fatalf("expected 2 test results, got %d", len(result.TestResults))
+	}
+	if want := "suite timeout exceeded (1s)"; result.TestResults[1].Passed || result.TestResults[1].Message != want {
+		t.Errorf("expected message %q for the remaining test, got passed=%v message=%q",
+			want, result.TestResults[1].Passed, result.TestResults[1].Message)
+	}
+}
@@ -1,7 +1,10 @@
 package runner
 
 import (
+	"context"
 	"encoding/json"
+	"strings"
+	"time"
 )
 
 // TestCase represents a single test case
@@ -9,6 +12,76 @@ type TestCase struct {
 	Description      string   `json:"description,omitempty"`
 	Request          Request  `json:"request"`
 	ExpectedResponse Response `json:"expected_response"`
+
+	// Disabled temporarily deactivates the test. Disabled tests are never sent to the
+	// handler and are counted separately from the suite's total. Unlike an intentional
+	// skip, a disabled test is expected to be re-enabled.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// DependsOn lists the IDs of tests that must run before this one, for ordering
+	// requirements that are not expressed by refs (e.g., a test relying on state set up
+	// by another). In suites that are not dependency-ordered, they must come earlier in
+	// the suite. They are part of the test's request chain like the creators of its refs.
+	DependsOn []string `json:"depends_on,omitempty"`
+
+	// Only focuses the suite on the test while iterating on it: if any test of a suite
+	// sets it, only these tests run, together with the tests they depend on in stateful
+	// suites. It is not meant to be committed.
+	Only bool `json:"only,omitempty"`
+
+	// Skip intentionally leaves the test out, e.g. because it does not apply to the
+	// handlers the suite is run against, for the reason given in SkipReason. Like
+	// disabled tests, skipped tests are never sent to the handler and are counted
+	// separately from the suite's total.
+	Skip       bool   `json:"skip,omitempty"`
+	SkipReason string `json:"skip_reason,omitempty"`
+
+	// Group names a set of related tests within a non-stateful suite that share a
+	// lifecycle: the BeforeHook of the group's first enabled test runs once, before any
+	// test of the group, and the AfterHook of its last enabled test runs once, after all
+	// of them. If the BeforeHook fails, the tests of the group fail without sending their
+	// requests. Groups do not affect the order in which tests run. When the runner
+	// isolates groups, the tests of a group share a dedicated handler process.
+	Group string `json:"group,omitempty"`
+
+	// ExpectedLogEntry asserts that the handler writes a JSON object to stderr while
+	// running the test that contains all of these key-value pairs. Values are compared
+	// as decoded JSON. Only handlers running as a subprocess capture stderr.
+	ExpectedLogEntry map[string]any `json:"expected_log_entry,omitempty"`
+
+	// ExpectedResponseTime documents the response time SLO of the test as a Go duration
+	// string (e.g., "50ms"). Exceeding it is logged as a warning, or fails the test if
+	// the runner enforces SLOs.
+	ExpectedResponseTime string `json:"expected_response_time,omitempty"`
+
+	// TimeoutMs overrides the handler timeout for this test, in milliseconds. Zero uses
+	// the runner's handler timeout. Only applies to handlers running as a subprocess.
+	TimeoutMs int `json:"timeout_ms,omitempty"`
+
+	// RetryCount is how many more times a failed test is run before it counts as
+	// failed, to tolerate transient handler errors. Only the last attempt is recorded.
+	RetryCount int `json:"retry_count,omitempty"`
+
+	// RequiredResultFields lists dot-separated paths (e.g., "block.header.version")
+	// that must exist in the result of a successful response, in addition to it
+	// matching the expected result.
+	RequiredResultFields []string `json:"required_result_fields,omitempty"`
+
+	// Tags label the test for selecting tests by tag, e.g. "slow" or "signet". A test
+	// also has the tags of its suite (see TestSuite.Tags).
+	Tags []string `json:"tags,omitempty"`
+
+	// AddedInVersion is the SuiteVersion in which the test was added. It allows running
+	// only tests added since a given version; tests without it are always run.
+	AddedInVersion string `json:"added_in_version,omitempty"`
+
+	// BeforeHook and AfterHook perform side effects around the test, such as writing
+	// a file the request refers to. They can only be set from Go, not in suite files.
+	// BeforeHook runs before the request is sent; if it fails, the test fails without
+	// sending the request. AfterHook runs after the test, whatever its outcome. In
+	// groups, the hooks of the first and last test apply to the whole group (see Group).
+	BeforeHook func(ctx context.Context) error `json:"-"`
+	AfterHook  func(ctx context.Context)       `json:"-"`
 }
 
 // TestSuite represents a collection of test cases
@@ -17,12 +90,154 @@ type TestSuite struct {
 	Description string     `json:"description,omitempty"`
 	Tests       []TestCase `json:"tests"`
 
+	// Tags label all tests of the suite, in addition to their own tags (see
+	// TestCase.Tags)
+	Tags []string `json:"tags,omitempty"`
+
 	// Stateful indicates that tests in this suite depend on each other and must
 	// execute sequentially. If any test fails in a stateful suite, all subsequent
 	// tests are automatically skipped and considered as failed. Use this for test
 	// suites where later tests depend on the success of earlier tests
 	// (e.g., setup -> operation -> verification).
 	Stateful bool `json:"stateful,omitempty"`
+
+	// ExecutionModel selects how the tests of the suite are executed. If empty, it is
+	// derived from Stateful (see Model).
+	ExecutionModel ExecutionModel `json:"execution_model,omitempty"`
+
+	// ContinueOnFailure keeps running the tests of a sequential or dependency-ordered
+	// suite after a test failed, instead of failing the remaining (or dependent) tests
+	// as skipped. Use it for suites that share a handler but whose tests mostly do not
+	// depend on the outcome of earlier ones.
+	ContinueOnFailure bool `json:"continue_on_failure,omitempty"`
+
+	// Metadata holds arbitrary annotations for the suite (e.g., spec section references,
+	// issue links, authors). Values are not interpreted by the runner and are passed
+	// through to the suite result as-is.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// EstimatedDuration is the expected wall-clock time to run the suite as a Go
+	// duration string (e.g., "2m30s"). It is used for progress ETAs and to warn when
+	// a suite runs much longer than expected.
+	EstimatedDuration string `json:"estimated_duration,omitempty"`
+
+	// TimeoutSeconds limits the wall-clock time of the suite run, in seconds. Once it is
+	// exceeded, the remaining tests fail with "suite timeout exceeded". Zero means no
+	// limit other than the total timeout and the handler timeout.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+
+	// RequiredHandlerVersion is a semantic version constraint (e.g., ">=1.2.0") the
+	// handler must satisfy for the suite to run. The version is queried via the
+	// __version__ protocol method; the suite is skipped if it is not satisfied.
+	RequiredHandlerVersion string `json:"required_handler_version,omitempty"`
+
+	// MinimumHandlerVersion and MaximumHandlerVersion document the inclusive range of
+	// handler versions the suite is written for (e.g., because it covers a feature added
+	// or removed in a specific version). Either may be empty for an open range. Unlike
+	// RequiredHandlerVersion they are not checked at runtime; cmd/lint-suite compares
+	// them against the supported handler versions.
+	MinimumHandlerVersion string `json:"minimum_handler_version,omitempty"`
+	MaximumHandlerVersion string `json:"maximum_handler_version,omitempty"`
+
+	// SuiteVersion is the semantic version of the suite itself (e.g., "2.1.0"), bumped
+	// when tests are added or changed. See TestCase.AddedInVersion.
+	SuiteVersion string `json:"suite_version,omitempty"`
+
+	// Handler is the path of a handler binary to run the suite against instead of the
+	// runner's handler, started with HandlerArgs, for runs that cover several handler
+	// implementations (e.g., one per library component). See TestRunner.WithHandler.
+	Handler     string   `json:"handler,omitempty"`
+	HandlerArgs []string `json:"handler_args,omitempty"`
+
+	// HandlerEnv lists environment variables, as "KEY=value" entries, the handler is
+	// started with while the suite runs (e.g., "BITCOIN_NETWORK=regtest"). The suite gets
+	// a handler of its own, so they do not leak into later suites. See
+	// TestRunner.WithHandlerEnv.
+	HandlerEnv []string `json:"handler_env,omitempty"`
+
+	// AssertHandlerEnv lists environment variables the handler process must see with
+	// exactly these values. They are queried via the __get_env__ protocol method before
+	// the first test; a mismatch is reported as a failed test.
+	AssertHandlerEnv map[string]string `json:"assert_handler_env,omitempty"`
+
+	// LogFile is a path to which every request sent and response received while the
+	// suite runs is written as newline-delimited JSON, each object carrying a "type" of
+	// "request" or "response". The file is truncated unless the runner appends logs.
+	LogFile string `json:"log_file,omitempty"`
+
+	// ArtifactDir is a directory (created if needed) the suite run is recorded to:
+	// requests.ndjson and responses.ndjson with every request and response,
+	// result.json with the TestResult, and failed/<test id>.json with the request and
+	// response of every failed test.
+	ArtifactDir string `json:"artifact_dir,omitempty"`
+
+	// BeforeSuiteRequest is sent to the handler before the first test, e.g. to prepare
+	// shared state. Its response is only checked for well-formedness; if the handler
+	// returns an error response the suite is skipped.
+	BeforeSuiteRequest *Request `json:"before_suite_request,omitempty"`
+
+	// AfterSuiteRequest is sent to the handler after the last test, regardless of the
+	// suite outcome. Failures are logged as warnings and do not affect the result.
+	AfterSuiteRequest *Request `json:"after_suite_request,omitempty"`
+
+	// BeforeEachTest is sent to the handler before every test that runs, e.g. to reset
+	// state. Its response is only checked for well-formedness; if the handler returns an
+	// error response the test is skipped.
+	BeforeEachTest *Request `json:"before_each_test,omitempty"`
+
+	// AfterEachTest is sent to the handler after every test that ran. Failures are
+	// logged as warnings and do not affect the result.
+	AfterEachTest *Request `json:"after_each_test,omitempty"`
+
+	// IDPattern is a regular expression every test ID in the suite must match in full
+	// (e.g., "btck_[a-z_]+_\\d+"). Suites with non-matching IDs fail to load.
+	IDPattern string `json:"id_pattern,omitempty"`
+}
+
+// ExecutionModel describes how the tests of a suite relate to each other
+type ExecutionModel string
+
+const (
+	// ExecutionSequential runs tests in file order against one handler. If any test
+	// fails, all subsequent tests are skipped. Equivalent to Stateful.
+	ExecutionSequential ExecutionModel = "sequential"
+	// ExecutionParallel marks tests as independent of each other. They are currently
+	// run in file order.
+	ExecutionParallel ExecutionModel = "parallel"
+	// ExecutionDependencyOrdered runs tests against one handler in an order where every
+	// test runs after the tests that create the refs it uses (see
+	// DependencyTracker.BuildExecutionOrder). If a test fails, only the tests that
+	// depend on it are skipped.
+	ExecutionDependencyOrdered ExecutionModel = "dependency_ordered"
+)
+
+// Model returns the effective execution model of the suite. For backward compatibility,
+// a suite without an explicit ExecutionModel is sequential if Stateful is set and
+// parallel otherwise.
+func (s *TestSuite) Model() ExecutionModel {
+	if s.ExecutionModel != "" {
+		return s.ExecutionModel
+	}
+	if s.Stateful {
+		return ExecutionSequential
+	}
+	return ExecutionParallel
+}
+
+// IsStateful reports whether the tests of the suite depend on handler state left by
+// earlier tests, and so must share a single handler process
+func (s *TestSuite) IsStateful() bool {
+	return s.Model() != ExecutionParallel
+}
+
+// EstimatedDurationValue returns the parsed EstimatedDuration, or zero if it is unset
+// or invalid. LoadTestSuiteFromFS rejects suites with an invalid estimated duration.
+func (s *TestSuite) EstimatedDurationValue() time.Duration {
+	d, err := time.ParseDuration(s.EstimatedDuration)
+	if err != nil {
+		return 0
+	}
+	return d
 }
 
 // Request represents a request sent to the handler
@@ -86,6 +301,74 @@ func (r Result) Normalize() (string, error) {
 	return string(normalized), nil
 }
 
+// IsSubsetOf reports whether r is contained in other. Objects match if every key of r
+// exists in other with a matching value, arrays match if they have the same length and
+// their elements match positionally, and scalars match if they are equal after
+// normalization (e.g., 1.0 equals 1). Returns false if either result is not valid JSON.
+func (r Result) IsSubsetOf(other Result) bool {
+	var sub, super interface{}
+	if err := json.Unmarshal(r, &sub); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(other, &super); err != nil {
+		return false
+	}
+	return isSubset(sub, super)
+}
+
+// isSubset implements IsSubsetOf for decoded JSON values
+func isSubset(sub, super interface{}) bool {
+	switch s := sub.(type) {
+	case map[string]interface{}:
+		o, ok := super.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, value := range s {
+			otherValue, ok := o[key]
+			if !ok || !isSubset(value, otherValue) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		o, ok := super.([]interface{})
+		if !ok || len(s) != len(o) {
+			return false
+		}
+		for i := range s {
+			if !isSubset(s[i], o[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		a, _ := json.Marshal(sub)
+		b, _ := json.Marshal(super)
+		return string(a) == string(b)
+	}
+}
+
+// HasField reports whether the dot-separated path (e.g., "block.header.version") exists
+// in r, traversing nested objects. A field whose value is null still exists. Returns
+// false if r is not valid JSON.
+func (r Result) HasField(path string) bool {
+	var value interface{}
+	if err := json.Unmarshal(r, &value); err != nil {
+		return false
+	}
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if value, ok = object[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
 // RefObject represents a reference type result structure.
 type RefObject struct {
 	Ref string `json:"ref"`
@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Validation error reasons
+const (
+	// ReasonIDPatternMismatch means a test ID does not match the suite's id_pattern
+	ReasonIDPatternMismatch = "IDPatternMismatch"
+	// ReasonDuplicateID means several tests share a test ID
+	ReasonDuplicateID = "DuplicateID"
+	// ReasonUnexpectedSuccess means the handler succeeded where an error was expected
+	ReasonUnexpectedSuccess = "UnexpectedSuccess"
+	// ReasonUnexpectedError means the handler returned an error where success was expected
+	ReasonUnexpectedError = "UnexpectedError"
+	// ReasonErrorCodeMismatch means the error code differs from the expected one
+	ReasonErrorCodeMismatch = "ErrorCodeMismatch"
+	// ReasonResultMismatch means the result differs from the expected one
+	ReasonResultMismatch = "ResultMismatch"
+	// ReasonMissingField means the result lacks one of the test's required_result_fields
+	ReasonMissingField = "MissingField"
+	// ReasonRefMismatch means the result is not the expected reference object
+	ReasonRefMismatch = "RefMismatch"
+	// ReasonInvalidResult means the result is not valid JSON
+	ReasonInvalidResult = "InvalidResult"
+	// ReasonLogEntryMissing means the handler did not log the expected log entry
+	ReasonLogEntryMissing = "LogEntryMissing"
+	// ReasonSLOExceeded means the handler exceeded the expected response time
+	ReasonSLOExceeded = "SLOExceeded"
+)
+
+// ValidationError describes a single test that does not meet an expectation. Reason is
+// a stable category name that can be aggregated, while Message is meant for humans.
+type ValidationError struct {
+	Reason  string `json:"reason"`
+	TestID  string `json:"test_id"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("test %s: %s", e.TestID, e.Message)
+}
+
+// newValidationError returns a ValidationError for a test with a formatted message
+func newValidationError(reason string, test *TestCase, format string, args ...any) *ValidationError {
+	return &ValidationError{
+		Reason:  reason,
+		TestID:  test.Request.ID,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// validateTestIDs checks every test ID against the suite's IDPattern, which must match
+// the whole ID. Returns an error if the pattern does not compile, or the joined
+// ValidationErrors of all mismatching IDs.
+func validateTestIDs(suite *TestSuite) error {
+	if suite.IDPattern == "" {
+		return nil
+	}
+	pattern, err := regexp.Compile(`^(?:` + suite.IDPattern + `)$`)
+	if err != nil {
+		return fmt.Errorf("invalid id_pattern: %w", err)
+	}
+
+	var errs []error
+	for _, test := range suite.Tests {
+		if !pattern.MatchString(test.Request.ID) {
+			errs = append(errs, &ValidationError{
+				Reason:  ReasonIDPatternMismatch,
+				TestID:  test.Request.ID,
+				Message: fmt.Sprintf("ID %q does not match id_pattern %q", test.Request.ID, suite.IDPattern),
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateSuiteIDs checks that no two tests share a test ID, within a suite or across
+// the given suites, as tools that look tests up by ID (like the mock handler) would
+// silently pick one of them. Returns the joined ValidationErrors of all duplicate IDs,
+// naming the suites of every test using the ID, in the order the IDs first appear.
+func ValidateSuiteIDs(suites []TestSuite) error {
+	var ids []string
+	usedIn := make(map[string][]string)
+	for _, suite := range suites {
+		for _, test := range suite.Tests {
+			id := test.Request.ID
+			if _, ok := usedIn[id]; !ok {
+				ids = append(ids, id)
+			}
+			usedIn[id] = append(usedIn[id], fmt.Sprintf("%q", suite.Name))
+		}
+	}
+
+	var errs []error
+	for _, id := range ids {
+		if names := usedIn[id]; len(names) > 1 {
+			errs = append(errs, &ValidationError{
+				Reason:  ReasonDuplicateID,
+				TestID:  id,
+				Message: fmt.Sprintf("ID is used by %d tests, in suites %s", len(names), strings.Join(names, ", ")),
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
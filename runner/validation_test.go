@@ -0,0 +1,118 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadTestSuiteFromFS_IDPattern(t *testing.T) {
+	fsys := fstest.MapFS{
+		"valid.json": &fstest.MapFile{Data: []byte(`{"id_pattern": "chain#\\d+", "tests": [
+			{"request": {"id": "chain#1", "method": "m"}, "expected_response": {}},
+			{"request": {"id": "chain#2", "method": "m"}, "expected_response": {}}
+		]}`)},
+		"mismatch.json": &fstest.MapFile{Data: []byte(`{"id_pattern": "chain#\\d+", "tests": [
+			{"request": {"id": "chain#1", "method": "m"}, "expected_response": {}},
+			{"request": {"id": "xchain#2", "method": "m"}, "expected_response": {}},
+			{"request": {"id": "chain#3b", "method": "m"}, "expected_response": {}}
+		]}`)},
+		"invalid.json": &fstest.MapFile{Data: []byte(`{"id_pattern": "chain#(", "tests": []}`)},
+	}
+
+	if _, err := LoadTestSuiteFromFS(fsys, "valid.json"); err != nil {
+		t.Errorf("failed to load suite with matching IDs: %v", err)
+	}
+
+	_, err := LoadTestSuiteFromFS(fsys, "mismatch.json")
+	if err == nil {
+		t.Fatalf("expected error for IDs not matching id_pattern")
+	}
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) || len(joined.Unwrap()) != 2 {
+		t.Fatalf("expected one error per mismatching ID, got: %v", err)
+	}
+	for i, wantID := range []string{"xchain#2", "chain#3b"} {
+		var validationErr *ValidationError
+		if !errors.As(joined.Unwrap()[i], &validationErr) {
+			t.Fatalf("expected ValidationError, got %T", joined.Unwrap()[i])
+		}
+		if validationErr.Reason != ReasonIDPatternMismatch || validationErr.TestID != wantID {
+			t.Errorf("unexpected validation error: %+v", validationErr)
+		}
+	}
+
+	if _, err := LoadTestSuiteFromFS(fsys, "invalid.json"); err == nil {
+		t.Errorf("expected error for invalid id_pattern")
+	}
+}
+
+func TestValidateSuiteIDs(t *testing.T) {
+	suite := func(name string, ids ...string) TestSuite {
+		s := TestSuite{Name: name}
+		for _, id := range ids {
+			s.Tests = append(s.Tests, TestCase{Request: Request{ID: id}})
+		}
+		return s
+	}
+
+	if err := ValidateSuiteIDs([]TestSuite{suite("A", "1", "2"), suite("B", "3")}); err != nil {
+		t.Errorf("expected no error for unique IDs, got: %v", err)
+	}
+
+	err := ValidateSuiteIDs([]TestSuite{suite("A", "1", "2", "1"), suite("B", "2", "3")})
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) || len(joined.Unwrap()) != 2 {
+		t.Fatalf("expected one error per duplicate ID, got: %v", err)
+	}
+	want := []string{
+		`test 1: ID is used by 2 tests, in suites "A", "A"`,
+		`test 2: ID is used by 2 tests, in suites "A", "B"`,
+	}
+	for i, w := range want {
+		var validationErr *ValidationError
+		if !errors.As(joined.Unwrap()[i], &validationErr) || validationErr.Reason != ReasonDuplicateID || validationErr.Error() != w {
+			t.Errorf("expected %q, got %v", w, joined.Unwrap()[i])
+		}
+	}
+}
+
+func TestRunTestSuite_ValidationErrors(t *testing.T) {
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		if req.Method == "fail" {
+			return Response{Error: &Error{Code: &ErrorCode{Type: "t", Member: "m"}}}
+		}
+		return Response{Result: Result(`1`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	suite := TestSuite{
+		Name: "Validation",
+		Tests: []TestCase{
+			{Request: Request{ID: "pass", Method: "ok"}, ExpectedResponse: Response{Result: Result(`1`)}},
+			{Request: Request{ID: "mismatch", Method: "ok"}, ExpectedResponse: Response{Result: Result(`2`)}},
+			{Request: Request{ID: "error", Method: "fail"}, ExpectedResponse: Response{Result: Result(`1`)}},
+		},
+	}
+
+	result := tr.RunTestSuite(context.Background(), suite, RunOptions{})
+	if result.TestResults[0].ValidationError != nil {
+		t.Errorf("expected no validation error for passing test, got %v", result.TestResults[0].ValidationError)
+	}
+	if len(result.ValidationErrors) != 2 {
+		t.Fatalf("expected 2 validation errors, got %v", result.ValidationErrors)
+	}
+	for i, want := range []struct{ id, reason string }{
+		{"mismatch", ReasonResultMismatch},
+		{"error", ReasonUnexpectedError},
+	} {
+		got := result.ValidationErrors[i]
+		if got.TestID != want.id || got.Reason != want.reason {
+			t.Errorf("validation error %d: expected %s/%s, got %+v", i, want.id, want.reason, got)
+		}
+	}
+}
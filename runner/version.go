@@ -0,0 +1,178 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// versionRequestID is the request ID used when probing the handler version
+const versionRequestID = "__ver__"
+
+// versionResult is the result returned by the __version__ protocol method
+type versionResult struct {
+	Version string `json:"version"`
+}
+
+// HandlerVersion returns the semantic version reported by the handler's __version__
+// method. The version is cached until the handler process is closed.
+func (tr *TestRunner) HandlerVersion() (string, error) {
+	if tr.handler != nil && tr.handlerVersion != "" {
+		return tr.handlerVersion, nil
+	}
+
+	if err := tr.SendRequest(Request{ID: versionRequestID, Method: "__version__"}); err != nil {
+		return "", err
+	}
+	resp, err := tr.ReadResponse()
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.Error != nil {
+		return "", fmt.Errorf("handler returned an error for __version__")
+	}
+
+	var result versionResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil || result.Version == "" {
+		return "", fmt.Errorf("unexpected __version__ result: %s", string(resp.Result))
+	}
+	if !semver.IsValid(canonicalVersion(result.Version)) {
+		return "", fmt.Errorf("handler reported invalid semantic version %q", result.Version)
+	}
+
+	tr.handlerVersion = result.Version
+	return result.Version, nil
+}
+
+// checkHandlerVersion returns a non-empty reason if the handler version does not
+// satisfy the constraint or cannot be determined
+func (tr *TestRunner) checkHandlerVersion(constraint string) string {
+	version, err := tr.HandlerVersion()
+	if err != nil {
+		return fmt.Sprintf("could not determine handler version for constraint %q: %v", constraint, err)
+	}
+	ok, err := SatisfiesVersion(version, constraint)
+	if err != nil {
+		return err.Error()
+	}
+	if !ok {
+		return fmt.Sprintf("handler version %s does not satisfy %s", version, constraint)
+	}
+	return ""
+}
+
+// SatisfiesVersion reports whether version satisfies a constraint. A constraint is a
+// comma-separated list of comparisons that must all hold, each consisting of an
+// optional operator (>=, >, <=, <, =, ==; default =) followed by a semantic version,
+// e.g. ">=1.2.0, <2.0.0". The "v" prefix is optional on all versions.
+func SatisfiesVersion(version, constraint string) (bool, error) {
+	v := canonicalVersion(version)
+	if !semver.IsValid(v) {
+		return false, fmt.Errorf("invalid semantic version %q", version)
+	}
+
+	for _, part := range strings.Split(constraint, ",") {
+		op, target, err := parseVersionComparison(part)
+		if err != nil {
+			return false, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+		}
+
+		cmp := semver.Compare(v, target)
+		var ok bool
+		switch op {
+		case ">=":
+			ok = cmp >= 0
+		case ">":
+			ok = cmp > 0
+		case "<=":
+			ok = cmp <= 0
+		case "<":
+			ok = cmp < 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ValidateVersionConstraint returns an error if the constraint cannot be parsed
+func ValidateVersionConstraint(constraint string) error {
+	for _, part := range strings.Split(constraint, ",") {
+		if _, _, err := parseVersionComparison(part); err != nil {
+			return fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+		}
+	}
+	return nil
+}
+
+// ValidateVersionRange returns an error if a bound of an inclusive version range is not a
+// semantic version or the minimum exceeds the maximum. Empty bounds are unbounded.
+func ValidateVersionRange(minimum, maximum string) error {
+	for _, bound := range []string{minimum, maximum} {
+		if bound != "" && !semver.IsValid(canonicalVersion(bound)) {
+			return fmt.Errorf("invalid semantic version %q", bound)
+		}
+	}
+	if minimum != "" && maximum != "" && semver.Compare(canonicalVersion(minimum), canonicalVersion(maximum)) > 0 {
+		return fmt.Errorf("minimum version %s exceeds maximum version %s", minimum, maximum)
+	}
+	return nil
+}
+
+// VersionRangesOverlap reports whether two inclusive version ranges share at least one
+// version. Empty bounds are unbounded.
+func VersionRangesOverlap(min1, max1, min2, max2 string) (bool, error) {
+	if err := ValidateVersionRange(min1, max1); err != nil {
+		return false, err
+	}
+	if err := ValidateVersionRange(min2, max2); err != nil {
+		return false, err
+	}
+	// notAfter reports whether lower <= upper, treating empty bounds as unbounded
+	notAfter := func(lower, upper string) bool {
+		return lower == "" || upper == "" || semver.Compare(canonicalVersion(lower), canonicalVersion(upper)) <= 0
+	}
+	return notAfter(min1, max2) && notAfter(min2, max1), nil
+}
+
+// AddedBefore reports whether the test was added in a version earlier than version.
+// Tests without a valid AddedInVersion are never considered added before.
+func (t *TestCase) AddedBefore(version string) bool {
+	added := canonicalVersion(t.AddedInVersion)
+	if t.AddedInVersion == "" || !semver.IsValid(added) {
+		return false
+	}
+	return semver.Compare(added, canonicalVersion(version)) < 0
+}
+
+// parseVersionComparison splits a single comparison such as ">=1.2.0" into its operator
+// and canonical version
+func parseVersionComparison(s string) (string, string, error) {
+	s = strings.TrimSpace(s)
+	op := "="
+	for _, candidate := range []string{">=", "<=", "==", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(s, candidate); ok {
+			op, s = candidate, strings.TrimSpace(rest)
+			break
+		}
+	}
+
+	v := canonicalVersion(s)
+	if !semver.IsValid(v) {
+		return "", "", fmt.Errorf("invalid semantic version %q", s)
+	}
+	return op, v, nil
+}
+
+// canonicalVersion adds the "v" prefix expected by golang.org/x/mod/semver
+func canonicalVersion(v string) string {
+	if strings.HasPrefix(v, "v") {
+		return v
+	}
+	return "v" + v
+}
@@ -0,0 +1,152 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSatisfiesVersion(t *testing.T) {
+	tests := []struct {
+		version    string
+		constraint string
+		want       bool
+		wantErr    bool
+	}{
+		{"1.2.0", ">=1.2.0", true, false},
+		{"1.1.9", ">=1.2.0", false, false},
+		{"v1.3.0", ">1.2.0", true, false},
+		{"1.2.0", ">1.2.0", false, false},
+		{"1.2.0", "1.2.0", true, false},
+		{"1.2.0", "==v1.2.0", true, false},
+		{"2.0.0", ">=1.2.0, <2.0.0", false, false},
+		{"1.9.9", ">=1.2.0, <2.0.0", true, false},
+		{"1.2.0", ">=banana", false, true},
+		{"banana", ">=1.2.0", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.constraint, func(t *testing.T) {
+			got, err := SatisfiesVersion(tt.version, tt.constraint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SatisfiesVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SatisfiesVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunTestSuite_RequiredHandlerVersion(t *testing.T) {
+	versionProbes := 0
+	tr, err := NewTestRunnerInProcess(func(req Request) Response {
+		if req.Method == "__version__" {
+			versionProbes++
+			return Response{Result: Result(`{"version": "1.2.0"}`)}
+		}
+		return Response{Result: Result(`true`)}
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to create in-process runner: %v", err)
+	}
+	defer tr.CloseHandler()
+
+	newSuite := func(constraint string) TestSuite {
+		return TestSuite{
+			Name:                   "Versioned",
+			RequiredHandlerVersion: constraint,
+			Tests: []TestCase{
+				{Request: Request{ID: "1", Method: "m"}, ExpectedResponse: Response{Result: Result(`true`)}},
+			},
+		}
+	}
+
+	result := tr.RunTestSuite(context.Background(), newSuite(">=1.0.0"), RunOptions{})
+	if result.Skipped || result.PassedTests != 1 {
+		t.Fatalf("expected satisfied suite to run, got skipped=%v passed=%d", result.Skipped, result.PassedTests)
+	}
+
+	result = tr.RunTestSuite(context.Background(), newSuite(">=2.0.0"), RunOptions{})
+	if !result.Skipped || result.TotalTests != 0 {
+		t.Fatalf("expected unsatisfied suite to be skipped, got skipped=%v total=%d", result.Skipped, result.TotalTests)
+	}
+	if !strings.Contains(result.SkipReason, "handler version 1.2.0 does not satisfy >=2.0.0") {
+		t.Errorf("unexpected skip reason: %q", result.SkipReason)
+	}
+
+	if versionProbes != 1 {
+		t.Errorf("expected handler version to be probed once, got %d", versionProbes)
+	}
+}
+
+func TestVersionRangesOverlap(t *testing.T) {
+	tests := []struct {
+		min1, max1, min2, max2 string
+		want                   bool
+		wantErr                bool
+	}{
+		{"1.0.0", "2.0.0", "1.5.0", "3.0.0", true, false},
+		{"1.0.0", "2.0.0", "2.0.0", "", true, false},
+		{"1.0.0", "2.0.0", "2.0.1", "", false, false},
+		{"", "0.9.0", "1.0.0", "", false, false},
+		{"", "", "1.0.0", "1.0.0", true, false},
+		{"v3.0.0", "", "1.0.0", "2.0.0", false, false},
+		{"2.0.0", "1.0.0", "", "", false, true},
+		{"latest", "", "", "", false, true},
+	}
+
+	for _, tt := range tests {
+		got, err := VersionRangesOverlap(tt.min1, tt.max1, tt.min2, tt.max2)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("VersionRangesOverlap(%q, %q, %q, %q) error = %v, wantErr %v", tt.min1, tt.max1, tt.min2, tt.max2, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("VersionRangesOverlap(%q, %q, %q, %q) = %v, want %v", tt.min1, tt.max1, tt.min2, tt.max2, got, tt.want)
+		}
+	}
+}
+
+func TestTestCase_AddedBefore(t *testing.T) {
+	tests := []struct {
+		added, since string
+		want         bool
+	}{
+		{"1.4.0", "1.5.0", true},
+		{"1.5.0", "1.5.0", false},
+		{"v2.0.0", "1.5.0", false},
+		{"", "1.5.0", false},
+	}
+
+	for _, tt := range tests {
+		test := TestCase{AddedInVersion: tt.added}
+		if got := test.AddedBefore(tt.since); got != tt.want {
+			t.Errorf("AddedBefore(%q) with added_in_version %q = %v, want %v", tt.since, tt.added, got, tt.want)
+		}
+	}
+}
+
+func TestLoadTestSuiteFromFS_SuiteVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"valid.json": &fstest.MapFile{Data: []byte(`{"suite_version": "2.1.0", "tests": [
+			{"request": {"id": "1", "method": "m"}, "expected_response": {}, "added_in_version": "2.1.0"},
+			{"request": {"id": "2", "method": "m"}, "expected_response": {}}
+		]}`)},
+		"invalid_suite.json": &fstest.MapFile{Data: []byte(`{"suite_version": "two", "tests": []}`)},
+		"future_test.json": &fstest.MapFile{Data: []byte(`{"suite_version": "2.1.0", "tests": [
+			{"request": {"id": "1", "method": "m"}, "expected_response": {}, "added_in_version": "2.2.0"}
+		]}`)},
+	}
+
+	if _, err := LoadTestSuiteFromFS(fsys, "valid.json"); err != nil {
+		t.Errorf("failed to load suite with valid versions: %v", err)
+	}
+	if _, err := LoadTestSuiteFromFS(fsys, "invalid_suite.json"); err == nil {
+		t.Errorf("expected error for invalid suite_version")
+	}
+	if _, err := LoadTestSuiteFromFS(fsys, "future_test.json"); err == nil {
+		t.Errorf("expected error for added_in_version later than suite_version")
+	}
+}